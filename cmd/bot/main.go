@@ -1,33 +1,59 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/app"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/config"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
 )
 
 func main() {
-	// Инициализируем логгер
+	// Инициализируем логгер, используемый до загрузки конфигурации
 	log := setupLogger()
+	// Загружаем конфигурацию из файла, переменных окружения и флагов
+	cfg := config.MustLoadConfig(log, os.Args[1:])
+	if cfg.PrintOnly() {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Error("failed to print config", logger.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
+	// Создаём корневой контекст, от которого наследуются контексты всех операций
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Создаём канал для приёма сигналов операционной системы
+	stop := make(chan os.Signal, 1)
+	// Передаём входящие сигналы в канал stop, SIGHUP перезагружает конфигурацию. Подписка оформляется до запуска
+	// приложения: иначе SIGHUP во время запуска завершил бы процесс, а SIGTERM - без корректной остановки
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	// Создаём приложение
-	application := app.NewApp(log)
+	application := app.NewApp(ctx, log, cfg)
 	// Запускаем его
 	application.MustStart()
-	// Создаём канал для приёма сигналов операционной системы
-	stop := make(chan os.Signal, 1)
-	// Передаём входящие сигналы в канал stop
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
-	// Читаем из канала, пока не придёт соответствующий сигнал
-	<-stop
+	// Читаем из канала, пока не придёт сигнал остановки
+	for sig := range stop {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		log.Info("reloading configuration")
+		application.Reload()
+	}
 
 	application.Stop()
 }
 
-// setupLogger инициализирует логгер с JSON-обработчиком
+// setupLogger инициализирует логгер с JSON-обработчиком, уровень и формат которого
+// затем переопределяются конфигурацией приложения
 func setupLogger() *slog.Logger {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	return logger
+	log, err := logger.New(os.Stdout, "info", logger.FormatJSON, logger.PIIHash, "")
+	if err != nil {
+		panic(err)
+	}
+	return log
 }
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// migrationName имя файла миграции: <версия-время>_<порядковый номер>_<название>.<sql|go>
+var migrationName = regexp.MustCompile(`^(\d{14})_(?:(\d+)_)?([a-z0-9_]+)\.(sql|go)$`)
+
+// versionFormat формат версии миграции
+const versionFormat = "20060102150405"
+
+// migrationFile описывает файл миграции в каталоге
+type migrationFile struct {
+	name    string
+	version int64
+	ordinal int
+	title   string
+	kind    string
+}
+
+// sqlTemplate шаблон SQL-миграции
+var sqlTemplate = template.Must(template.New("sql").Parse(`-- +goose Up
+
+
+-- +goose Down
+
+`))
+
+// goTemplate шаблон Go-миграции, регистрируется в goose при импорте пакета migrations
+var goTemplate = template.Must(template.New("go").Parse(`package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(up{{.Name}}, down{{.Name}})
+}
+
+// up{{.Name}} применяет миграцию
+func up{{.Name}}(ctx context.Context, tx *sql.Tx) error {
+	return nil
+}
+
+// down{{.Name}} откатывает миграцию
+func down{{.Name}}(ctx context.Context, tx *sql.Tx) error {
+	return nil
+}
+`))
+
+// listMigrations возвращает файлы миграций каталога по возрастанию версий.
+// Файлы, не похожие на миграции, пропускаются, имена с неверным форматом возвращаются ошибкой
+func listMigrations(fsys fs.FS) ([]migrationFile, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []migrationFile
+	var errs []error
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+		if e.IsDir() || (ext != ".sql" && ext != ".go") || e.Name() == "migrations.go" || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			errs = append(errs, fmt.Errorf("%s: name must look like <YYYYMMDDhhmmss>_<NN>_<name>.<sql|go>", e.Name()))
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		ordinal, _ := strconv.Atoi(m[2])
+		files = append(files, migrationFile{name: e.Name(), version: version, ordinal: ordinal, title: m[3], kind: m[4]})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].version < files[j].version })
+	return files, errors.Join(errs...)
+}
+
+// createMigration создаёт в каталоге dir файл миграции kind (sql или go) со следующим порядковым номером
+func createMigration(dir, name, kind string, now time.Time) (string, error) {
+	if kind != "sql" && kind != "go" {
+		return "", fmt.Errorf("%w: migration type must be sql or go, got %q", errUsage, kind)
+	}
+	title := strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if title == "" {
+		return "", fmt.Errorf("%w: migration name %q has no letters or digits", errUsage, name)
+	}
+
+	files, err := listMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", err
+	}
+	ordinal := 1
+	version := now.UTC()
+	for _, f := range files {
+		ordinal = max(ordinal, f.ordinal+1)
+		// Версия новой миграции всегда больше существующих, даже если часы отстают или файлы созданы в одну секунду
+		if last, err := time.Parse(versionFormat, strconv.FormatInt(f.version, 10)); err == nil && !version.After(last) {
+			version = last.Add(time.Second)
+		}
+	}
+
+	filename := fmt.Sprintf("%s_%02d_%s.%s", version.Format(versionFormat), ordinal, title, kind)
+	f, err := os.OpenFile(filepath.Join(dir, filename), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	tmpl := sqlTemplate
+	if kind == "go" {
+		tmpl = goTemplate
+	}
+	// Имя функций Go-миграции строится из версии, чтобы не пересекаться с другими миграциями пакета
+	if err = tmpl.Execute(f, struct{ Name string }{Name: version.Format(versionFormat)}); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filename), nil
+}
+
+// fixMigrations перенумеровывает порядковые номера в именах файлов миграций по возрастанию версий,
+// например после слияния веток с миграциями под одинаковыми номерами. Версии не меняются,
+// поэтому уже применённые миграции остаются применёнными
+func fixMigrations(w io.Writer, dir string, dryRun bool) error {
+	files, err := listMigrations(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+
+	renamed := 0
+	for i, f := range files {
+		want := fmt.Sprintf("%d_%02d_%s.%s", f.version, i+1, f.title, f.kind)
+		if want == f.name {
+			continue
+		}
+		renamed++
+		fmt.Fprintf(w, "%s => %s\n", f.name, want)
+		if dryRun {
+			continue
+		}
+		if err = os.Rename(filepath.Join(dir, f.name), filepath.Join(dir, want)); err != nil {
+			return err
+		}
+	}
+	if renamed == 0 {
+		fmt.Fprintln(w, "nothing to fix")
+	}
+	return nil
+}
+
+// validateMigrations проверяет имена, версии, порядковые номера и разметку goose встроенных SQL-миграций
+func validateMigrations(fsys fs.FS) error {
+	files, err := listMigrations(fsys)
+	errs := []error{err}
+	seen := make(map[int64]string, len(files))
+	for i, f := range files {
+		if prev, ok := seen[f.version]; ok {
+			errs = append(errs, fmt.Errorf("%s: version %d is also used by %s", f.name, f.version, prev))
+		}
+		seen[f.version] = f.name
+		if f.ordinal != i+1 {
+			errs = append(errs, fmt.Errorf("%s: ordinal must be %02d, run fix", f.name, i+1))
+		}
+		if f.kind != "sql" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, f.name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, _, err = parseSQLMigration(string(content)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// printPlan выводит операторы миграций, которые выполнила бы команда, не применяя их
+func printPlan(w io.Writer, fsys fs.FS, command string, current, target int64) error {
+	all, err := goose.CollectMigrations(".", 0, goose.MaxVersion)
+	if err != nil {
+		return err
+	}
+
+	type step struct {
+		migration *goose.Migration
+		up        bool
+	}
+	var steps []step
+	switch command {
+	case "up", "up-by-one", "up-to":
+		for _, m := range all {
+			if m.Version > current && (command != "up-to" || m.Version <= target) {
+				steps = append(steps, step{migration: m, up: true})
+				if command == "up-by-one" {
+					break
+				}
+			}
+		}
+	case "down", "redo":
+		for _, m := range all {
+			if m.Version == current {
+				steps = append(steps, step{migration: m})
+				if command == "redo" {
+					steps = append(steps, step{migration: m, up: true})
+				}
+			}
+		}
+	case "down-to":
+		for i := len(all) - 1; i >= 0; i-- {
+			if all[i].Version > target && all[i].Version <= current {
+				steps = append(steps, step{migration: all[i]})
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "-- current version: %d\n", current)
+	if len(steps) == 0 {
+		fmt.Fprintln(w, "-- no migrations to run")
+		return nil
+	}
+	for _, s := range steps {
+		direction := "down"
+		if s.up {
+			direction = "up"
+		}
+		fmt.Fprintf(w, "\n-- %s %d %s\n", direction, s.migration.Version, path.Base(s.migration.Source))
+		if path.Ext(s.migration.Source) == ".go" {
+			fmt.Fprintln(w, "-- Go migration, statements are not available")
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, s.migration.Source)
+		if err != nil {
+			return err
+		}
+		up, down, err := parseSQLMigration(string(content))
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.migration.Source, err)
+		}
+		statements := down
+		if s.up {
+			statements = up
+		}
+		for _, stmt := range statements {
+			fmt.Fprintln(w, stmt)
+		}
+	}
+	return nil
+}
+
+// parseSQLMigration разбирает SQL-миграцию goose на операторы разделов Up и Down.
+// Операторы разделяются точкой с запятой в конце строки, блоки StatementBegin/StatementEnd считаются одним оператором
+func parseSQLMigration(content string) (up, down []string, err error) {
+	const (
+		sectionNone = iota
+		sectionUp
+		sectionDown
+	)
+	section := sectionNone
+	inBlock := false
+	var buf strings.Builder
+
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt == "" {
+			return
+		}
+		if section == sectionUp {
+			up = append(up, stmt)
+		} else {
+			down = append(down, stmt)
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if annotation, ok := strings.CutPrefix(trimmed, "-- +goose "); ok {
+			switch strings.ToLower(strings.TrimSpace(annotation)) {
+			case "up":
+				if section != sectionNone {
+					return nil, nil, fmt.Errorf("line %d: duplicate -- +goose Up", n)
+				}
+				section = sectionUp
+			case "down":
+				if section != sectionUp || inBlock {
+					return nil, nil, fmt.Errorf("line %d: -- +goose Down must follow the Up section", n)
+				}
+				flush()
+				section = sectionDown
+			case "statementbegin":
+				if inBlock {
+					return nil, nil, fmt.Errorf("line %d: nested StatementBegin", n)
+				}
+				flush()
+				inBlock = true
+			case "statementend":
+				if !inBlock {
+					return nil, nil, fmt.Errorf("line %d: StatementEnd without StatementBegin", n)
+				}
+				flush()
+				inBlock = false
+			}
+			continue
+		}
+
+		if section == sectionNone {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, nil, fmt.Errorf("line %d: statement before -- +goose Up", n)
+			}
+			continue
+		}
+		if trimmed == "" && buf.Len() == 0 {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case section == sectionNone:
+		return nil, nil, errors.New("missing -- +goose Up")
+	case inBlock:
+		return nil, nil, errors.New("missing StatementEnd")
+	case strings.TrimSpace(buf.String()) != "" && !isComment(buf.String()):
+		return nil, nil, errors.New("last statement is not terminated with a semicolon")
+	}
+	return up, down, nil
+}
+
+// isComment проверяет, что текст состоит только из комментариев
+func isComment(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+			return false
+		}
+	}
+	return true
+}
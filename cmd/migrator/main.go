@@ -2,56 +2,287 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/config"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/storage/postgres"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/storage/postgres/migrations"
 	_ "github.com/lib/pq"
 	"github.com/pressly/goose/v3"
 )
 
-func main() {
-	var (
-		migrationsDir = os.Getenv("DIR")
-		dsn           = os.Getenv("DSN")
-		driverName    = "postgres"
-	)
+// Коды завершения мигратора, на которые могут полагаться скрипты
+const (
+	exitOK = 0
+	// exitError команда завершилась ошибкой, в том числе не прошла проверка миграций
+	exitError = 1
+	// exitUsage неизвестная команда или неверные аргументы
+	exitUsage = 2
+	// exitLocked блокировку миграций держит другой процесс дольше --lock-timeout
+	exitLocked = 3
+	// exitTimeout команда не уложилась в --timeout
+	exitTimeout = 4
+)
+
+// defaultMigrationsDir каталог с исходными файлами миграций, в нём create и fix создают и переименовывают файлы
+const defaultMigrationsDir = "internal/storage/postgres/migrations"
+
+// usage текст справки мигратора
+const usage = `Использование: migrator [флаги] <команда> [аргументы]
+
+Команды:
+  up                      применить все новые миграции
+  up-by-one               применить одну следующую миграцию
+  up-to ВЕРСИЯ            применить миграции до версии ВЕРСИЯ включительно
+  down                    откатить последнюю миграцию
+  down-to ВЕРСИЯ          откатить миграции новее версии ВЕРСИЯ (0 - откатить все)
+  redo                    откатить и заново применить последнюю миграцию
+  status                  показать состояние всех миграций
+  version                 показать текущую версию схемы базы данных
+  validate                проверить встроенные миграции без подключения к базе данных
+  create ИМЯ [sql|go]     создать миграцию из шаблона в каталоге --dir (по умолчанию sql)
+  fix                     перенумеровать порядковые номера в именах файлов миграций по возрастанию версий
+
+Флаги:
+%s
+Переменные окружения:
+  DSN, DSN_FILE           строка подключения к базе данных или путь к файлу с ней
+  DB_DRIVER_NAME          драйвер базы данных (по умолчанию postgres)
 
-	flag.Parse()
+Коды завершения:
+  0 - успех, 1 - ошибка выполнения или проверки, 2 - неверная команда или аргументы,
+  3 - не удалось получить блокировку миграций за --lock-timeout, 4 - превышен --timeout
+`
+
+// errUsage ошибка в команде или её аргументах
+var errUsage = errors.New("invalid usage")
+
+// options флаги командной строки мигратора
+type options struct {
+	dir         string
+	dryRun      bool
+	timeout     time.Duration
+	lockTimeout time.Duration
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
+// run выполняет команду мигратора и возвращает код завершения
+func run(args []string, stdout, stderr io.Writer) int {
 	log := setupLogger()
 
-	db, err := sqlx.Open(driverName, dsn)
+	var opts options
+	flags := flag.NewFlagSet("migrator", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.dir, "dir", defaultMigrationsDir, "каталог с исходными файлами миграций для create и fix")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "вывести SQL-операторы или переименования, ничего не меняя")
+	flags.DurationVar(&opts.timeout, "timeout", 5*time.Minute, "ограничение времени выполнения команды")
+	flags.DurationVar(&opts.lockTimeout, "lock-timeout", time.Minute, "ограничение времени ожидания блокировки миграций")
+	flags.Usage = func() {
+		var defaults strings.Builder
+		flags.SetOutput(&defaults)
+		flags.PrintDefaults()
+		flags.SetOutput(stderr)
+		fmt.Fprintf(stderr, usage, defaults.String())
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+	err := runCommand(ctx, log, stdout, opts, command, commandArgs)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(stderr, err)
+		flags.Usage()
+		return exitUsage
+	case errors.Is(err, postgres.ErrMigrationsLocked):
+		log.Error("migrations lock is not acquired", logger.Err(err), slog.String("command", command))
+		return exitLocked
+	case ctx.Err() != nil:
+		log.Error("command timed out", logger.Err(err), slog.String("command", command))
+		return exitTimeout
+	default:
+		log.Error("command failed", logger.Err(err), slog.String("command", command))
+		return exitError
+	}
+}
+
+// runCommand выполняет одну команду мигратора
+func runCommand(ctx context.Context, log *slog.Logger, stdout io.Writer, opts options, command string, args []string) error {
+	// Команды, работающие только с файлами миграций
+	switch command {
+	case "create":
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("%w: create expects a name and an optional type", errUsage)
+		}
+		kind := "sql"
+		if len(args) == 2 {
+			kind = args[1]
+		}
+		path, err := createMigration(opts.dir, args[0], kind, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, path)
+		return nil
+	case "fix":
+		if err := expectArgs(command, args, 0); err != nil {
+			return err
+		}
+		return fixMigrations(stdout, opts.dir, opts.dryRun)
+	case "validate":
+		if err := expectArgs(command, args, 0); err != nil {
+			return err
+		}
+		if err := validateMigrations(migrations.FS); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, "migrations are valid")
+		return nil
+	}
+
+	// Команды, работающие с базой данных
+	var version int64
+	switch command {
+	case "up", "up-by-one", "down", "redo", "status", "version":
+		if err := expectArgs(command, args, 0); err != nil {
+			return err
+		}
+	case "up-to", "down-to":
+		if err := expectArgs(command, args, 1); err != nil {
+			return err
+		}
+		v, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("%w: %s expects a non-negative version, got %q", errUsage, command, args[0])
+		}
+		version = v
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+
+	db, err := connect(log)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	current, err := db.SchemaVersion(ctx)
 	if err != nil {
-		log.Error("error opening database connection", slog.String("error", err.Error()))
-		os.Exit(1)
+		return err
+	}
+
+	switch command {
+	case "version":
+		fmt.Fprintln(stdout, current)
+		return nil
+	case "status":
+		return goose.StatusContext(ctx, db.DB.DB, ".")
 	}
 
-	if err = db.Ping(); err != nil {
-		log.Error("error pinging database", slog.String("error", err.Error()))
-		os.Exit(1)
+	if opts.dryRun {
+		return printPlan(stdout, migrations.FS, command, current, version)
 	}
 
-	args := flag.Args()
-	if len(args) == 0 {
-		log.Error("command is required: up, down, status")
-		os.Exit(1)
+	// Изменяющие схему команды выполняются под той же блокировкой, что и AUTO_MIGRATE в боте
+	lockCtx, cancelLock := context.WithTimeout(ctx, opts.lockTimeout)
+	unlock, err := db.LockMigrations(lockCtx)
+	cancelLock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	command := args[0]
+	if command == "up" || command == "up-by-one" || command == "up-to" {
+		if err = db.CheckSchemaVersion(ctx); err != nil {
+			return err
+		}
+	}
 
-	if err = goose.RunContext(context.Background(), command, db.DB, migrationsDir, args[1:]...); err != nil {
-		log.Error("error running migrations", slog.String("error", err.Error()))
-		os.Exit(1)
+	switch command {
+	case "up":
+		err = goose.UpContext(ctx, db.DB.DB, ".")
+	case "up-by-one":
+		err = goose.UpByOneContext(ctx, db.DB.DB, ".")
+	case "up-to":
+		err = goose.UpToContext(ctx, db.DB.DB, ".", version)
+	case "down":
+		err = goose.DownContext(ctx, db.DB.DB, ".")
+	case "down-to":
+		err = goose.DownToContext(ctx, db.DB.DB, ".", version)
+	case "redo":
+		err = goose.RedoContext(ctx, db.DB.DB, ".")
+	}
+	if err != nil {
+		return err
 	}
 
 	log.Info("migrations complete", slog.String("command", command))
+	return nil
+}
 
+// connect подключается к базе данных и настраивает goose на встроенные миграции
+func connect(log *slog.Logger) (*postgres.Storage, error) {
+	driverName := os.Getenv("DB_DRIVER_NAME")
+	if driverName == "" {
+		driverName = "postgres"
+	}
+	// Строка подключения содержит пароль, поэтому может быть передана файлом через DSN_FILE
+	dsn, err := config.LookupSecret("DSN")
+	if err != nil {
+		return nil, err
+	}
+	if dsn == "" {
+		return nil, fmt.Errorf("%w: DSN or DSN_FILE is required", errUsage)
+	}
+
+	if err = goose.SetDialect(driverName); err != nil {
+		return nil, err
+	}
+	// Миграции встроены в исполняемый файл, каталог с ними в контейнере не нужен
+	goose.SetBaseFS(migrations.FS)
+
+	return postgres.NewStorage(log, driverName, dsn)
 }
 
-// setupLogger инициализирует логгер с JSON-обработчиком
+// expectArgs проверяет количество аргументов команды
+func expectArgs(command string, args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("%w: %s expects %d argument(s), got %d", errUsage, command, n, len(args))
+	}
+	return nil
+}
+
+// setupLogger инициализирует логгер с JSON-обработчиком. Логи пишутся в stderr,
+// чтобы вывод команд (version, status, --dry-run) можно было разбирать скриптами
 func setupLogger() *slog.Logger {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	return logger
+	log, err := logger.New(os.Stderr, "debug", logger.FormatJSON, logger.PIIHash, "")
+	if err != nil {
+		panic(err)
+	}
+	return log
 }
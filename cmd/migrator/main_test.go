@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStdout string
+	}{
+		{name: "no command", args: nil, want: exitUsage},
+		{name: "help", args: []string{"--help"}, want: exitOK},
+		{name: "unknown flag", args: []string{"--unknown", "up"}, want: exitUsage},
+		{name: "unknown command", args: []string{"sideways"}, want: exitUsage},
+		{name: "validate", args: []string{"validate"}, want: exitOK, wantStdout: "migrations are valid"},
+		{name: "validate with arguments", args: []string{"validate", "extra"}, want: exitUsage},
+		{name: "up with arguments", args: []string{"up", "extra"}, want: exitUsage},
+		{name: "up-to without version", args: []string{"up-to"}, want: exitUsage},
+		{name: "up-to with invalid version", args: []string{"up-to", "latest"}, want: exitUsage},
+		{name: "down-to with negative version", args: []string{"down-to", "-1"}, want: exitUsage},
+		{name: "create without name", args: []string{"--dir", dir, "create"}, want: exitUsage},
+		{name: "create", args: []string{"--dir", dir, "create", "add_column"}, want: exitOK, wantStdout: "add_column.sql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(tt.args, &stdout, &stderr); got != tt.want {
+				t.Errorf("exit code = %d, want %d, stderr: %s", got, tt.want, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.sql")); len(files) != 1 {
+		t.Errorf("created %d migration files, want 1", len(files))
+	}
+}
+
+func TestRunRequiresDSN(t *testing.T) {
+	if _, ok := os.LookupEnv("DSN_FILE"); ok {
+		t.Skip("DSN_FILE is set")
+	}
+	t.Setenv("DSN", "")
+
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"version"}, &stdout, &stderr); got != exitUsage {
+		t.Errorf("exit code = %d, want %d", got, exitUsage)
+	}
+}
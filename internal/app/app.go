@@ -1,63 +1,165 @@
 package app
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/middleware"
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/client/event"
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/config"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/scheduler"
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/service"
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/storage/postgres"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/ticket"
 )
 
 // App описывает микросервис целиком, единая точка входа для всего микросервиса
 type App struct {
-	log      *slog.Logger
-	Bot      *bot.Bot
-	Database *postgres.Storage
-	Client   *event.Client
+	log *slog.Logger
+	// ctx корневой контекст приложения, его отмена прерывает все выполняющиеся операции
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	Bot       *bot.Bot
+	Database  *postgres.Storage
+	Client    *event.Client
+	Scheduler *scheduler.Scheduler
+	Config    *config.Reloader
+
+	// shutdownTimeout сколько ждать завершения обработки уже полученных обновлений при остановке
+	shutdownTimeout time.Duration
 }
 
-// NewApp конструктор для App
-func NewApp(log *slog.Logger) *App {
-	// Инициализируем конфиг
-	cfg := newCfg(log)
+// NewApp конструктор для App, контексты всех операций приложения создаются от ctx
+func NewApp(ctx context.Context, log *slog.Logger, cfg *config.Config) *App {
+	// Пересоздаём логгер с уровнем, форматом и обработкой персональных данных из конфигурации
+	log = newLogger(log, cfg)
 	// Создаём gRPC-клиент для отправки запросов
 	client := newClient(log, cfg)
 	// Создаём подключение к базе данных
 	db := dbConn(log, cfg)
+	// Применяем миграции или проверяем, что схема базы данных поддерживается
+	prepareSchema(ctx, log, cfg, db)
 	// Инициализируем сервисный слой
-	srvc := service.NewService(log, client, client, db)
+	srvc := service.NewService(log, service.Deps{
+		EventReceiver:       client,
+		UserRegister:        client,
+		RegistrationRemover: client,
+		UserSaver:           db,
+		UserStatus:          db,
+		UserData:            db,
+		CheckIns:            db,
+		Tickets:             newTicketSigner(cfg),
+		Feedback:            db,
+		UserSettings:        db,
+		EventExtras:         db,
+		Registrations:       db,
+		Outbox:              newRegistrationOutbox(cfg, db),
+		OutboxPolicy:        newOutboxPolicy(cfg),
+		EventChanges:        newEventChangeSource(log, cfg, client, db),
+		Notifications:       db,
+		Subscriptions:       db,
+		DefaultLocation:     cfg.GetDefaultLocation(),
+		PastEventsGrace:     cfg.GetPastEventsGrace(),
+		EventDuration:       cfg.GetEventDuration(),
+	})
 
 	b := newBot(log, cfg, srvc)
+	b.SetBroadcastRate(cfg.GetBroadcastRate())
+
+	// Регистрируем фоновые задачи
+	sched := scheduler.NewScheduler(log, cfg.GetJobTimeout())
+	if cfg.IsFeedbackSurveysEnabled() {
+		sched.Add("feedback_surveys", cfg.GetFeedbackCheckInterval(), b.SendFeedbackSurveys)
+	}
+	if cfg.IsRegistrationOutboxEnabled() {
+		sched.Add("registration_outbox", cfg.GetOutboxInterval(), b.DeliverQueuedRegistrations)
+	}
+	if cfg.IsEventChangeNoticesEnabled() {
+		sched.Add("event_changes", cfg.GetEventChangesInterval(), b.NotifyEventChanges)
+	}
+	sched.Add("new_event_announcements", cfg.GetAnnounceInterval(), b.AnnounceNewEvents)
+	sched.Add("registrations_reconcile", cfg.GetReconcileInterval(), func(ctx context.Context) {
+		if _, err := srvc.ReconcileRegistrations(ctx); err != nil {
+			log.ErrorContext(ctx, "reconciling registrations failed", logger.Err(err))
+		}
+	})
+
+	// Подписываем компоненты на перезагрузку конфигурации
+	reloader := config.NewReloader(log, cfg)
+	reloader.Subscribe(func(c *config.Config) {
+		b.UpdateSettings(botSettings(c))
+		b.SetRateLimits(
+			c.GetUserRateLimit(), c.GetUserRateBurst(),
+			c.GetGlobalRateLimit(), c.GetGlobalRateBurst(),
+			c.GetRateLimitBanThreshold(), c.GetRateLimitBanDuration(),
+		)
+		sched.SetInterval("feedback_surveys", c.GetFeedbackCheckInterval())
+		sched.SetInterval("registrations_reconcile", c.GetReconcileInterval())
+		sched.SetInterval("registration_outbox", c.GetOutboxInterval())
+		sched.SetInterval("event_changes", c.GetEventChangesInterval())
+		sched.SetInterval("new_event_announcements", c.GetAnnounceInterval())
+		b.SetBroadcastRate(c.GetBroadcastRate())
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
 
 	return &App{
-		log:      log,
-		Bot:      b,
-		Database: db,
-		Client:   client,
+		log:       log,
+		ctx:       ctx,
+		cancel:    cancel,
+		Bot:       b,
+		Database:  db,
+		Client:    client,
+		Scheduler: sched,
+		Config:    reloader,
+
+		shutdownTimeout: cfg.GetShutdownTimeout(),
 	}
 }
 
 // MustStart запускает приложение
 func (app *App) MustStart() {
 	app.log.Info("application successfully started")
-	go app.Bot.MustStart()
+	go app.Bot.MustStart(app.ctx)
+	app.Scheduler.Start(app.ctx)
+}
+
+// Reload перечитывает конфигурацию и применяет настройки, которые можно изменить без перезапуска.
+// При ошибке продолжает работать прежняя конфигурация, причина уже записана в лог Reloader'ом
+func (app *App) Reload() {
+	_ = app.Config.Reload()
 }
 
 // Stop реализует GracefulShutdown для всего микросервиса
 func (app *App) Stop() {
 	app.log.Info("shutting down...")
-	app.Bot.Stop()
+	app.Scheduler.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), app.shutdownTimeout)
+	defer cancel()
+	// Закрываем соединения только после того, как обработчики обновлений перестали к ним обращаться.
+	// Если они не уложились в отведённое время, отменяем корневой контекст, прерывая их операции
+	if err := app.Bot.Stop(ctx); err != nil {
+		app.log.Error("cancelling outstanding operations", logger.Err(err))
+	}
+	app.cancel()
+	app.Client.Close()
 	app.Database.Close()
 }
 
-// newCfg обёртка для инициализации объекта конфигурации
-func newCfg(log *slog.Logger) *config.Config {
-	cfg := config.MustLoadConfig(log)
-	log.Info("config successfully loaded")
-	return cfg
+// newLogger обёртка для создания логгера по конфигурации
+func newLogger(log *slog.Logger, cfg *config.Config) *slog.Logger {
+	configured, err := logger.New(os.Stdout, cfg.GetLogLevel(), cfg.GetLogFormat(), cfg.GetLogPIIMode(), cfg.GetLogPIISalt())
+	if err != nil {
+		log.Error("failed to create logger", logger.Err(err))
+		os.Exit(1)
+	}
+	return configured
 }
 
 // dbConn обёртка для установки соединения к базе данных
@@ -70,17 +172,91 @@ func dbConn(log *slog.Logger, cfg *config.Config) *postgres.Storage {
 	return db
 }
 
+// prepareSchema обёртка для применения миграций при запуске (AUTO_MIGRATE) и проверки версии схемы базы данных.
+// Бот не запускается, если схема новее, чем он поддерживает
+func prepareSchema(ctx context.Context, log *slog.Logger, cfg *config.Config, db *postgres.Storage) {
+	if !cfg.IsAutoMigrateEnabled() {
+		if err := db.CheckSchemaVersion(ctx); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.GetMigrationTimeout())
+	err := db.Migrate(ctx)
+	cancel()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// newTicketSigner обёртка для создания подписи билетов, возвращает nil, если секрет не задан
+func newTicketSigner(cfg *config.Config) service.TicketSigner {
+	if cfg.GetTicketSecret() == "" {
+		return nil
+	}
+	return ticket.NewSigner(cfg.GetTicketSecret())
+}
+
+// newRegistrationOutbox обёртка для создания очереди заявок на регистрацию, возвращает nil, если очередь отключена
+func newRegistrationOutbox(cfg *config.Config, db *postgres.Storage) service.RegistrationOutbox {
+	if !cfg.IsRegistrationOutboxEnabled() {
+		return nil
+	}
+	return db
+}
+
+// newEventChangeSource обёртка для создания подписчика на изменения событий, возвращает nil, если уведомления отключены
+func newEventChangeSource(log *slog.Logger, cfg *config.Config, client *event.Client, db *postgres.Storage) service.EventChangeSource {
+	if !cfg.IsEventChangeNoticesEnabled() {
+		return nil
+	}
+	return event.NewSubscriber(log, client, db)
+}
+
+// newOutboxPolicy обёртка для создания политики повторной отправки заявок из очереди
+func newOutboxPolicy(cfg *config.Config) service.OutboxPolicy {
+	return service.OutboxPolicy{
+		MaxAttempts:  cfg.GetOutboxMaxAttempts(),
+		RetryBackoff: cfg.GetOutboxRetryBackoff(),
+	}
+}
+
 // newBot обёртка для создания нового экземпляра BotAPI по токену
 func newBot(log *slog.Logger, cfg *config.Config, srvc *service.Service) *bot.Bot {
-	b, err := bot.NewBot(log, cfg.GetTelegramBotToken(), srvc)
+	limiter := middleware.NewRateLimiter(log,
+		cfg.GetUserRateLimit(), cfg.GetUserRateBurst(),
+		cfg.GetGlobalRateLimit(), cfg.GetGlobalRateBurst(),
+		cfg.GetRateLimitBanThreshold(), cfg.GetRateLimitBanDuration(),
+	)
+	b, err := bot.NewBot(log, srvc, limiter, bot.Options{
+		Token:                cfg.GetTelegramBotToken(),
+		PollerTimeout:        cfg.GetPollerTimeout(),
+		Workers:              cfg.GetUpdateWorkers(),
+		RequestTimeout:       cfg.GetRequestTimeout(),
+		ProfileFlushInterval: cfg.GetProfileFlushInterval(),
+		ProfileFlushTimeout:  cfg.GetProfileFlushTimeout(),
+		Settings:             botSettings(cfg),
+	})
 	if err != nil {
-		log.Error("failed to create bot", "error", err)
+		log.Error("failed to create bot", logger.Err(err))
 		os.Exit(1)
 	}
 	log.Info("bot successfully created")
 	return b
 }
 
+// botSettings собирает перезагружаемые настройки обработчиков бота из конфигурации
+func botSettings(cfg *config.Config) bot.Settings {
+	return bot.Settings{
+		AdminIDs:       cfg.GetAdminIDs(),
+		StaffIDs:       cfg.GetCheckInStaffIDs(),
+		PageSize:       cfg.GetPageSize(),
+		SearchEnabled:  cfg.IsSearchEnabled(),
+		WelcomeMessage: cfg.GetWelcomeMessage(),
+	}
+}
+
 // newClient обёртка для создания gRPC-клиента
 func newClient(log *slog.Logger, cfg *config.Config) *event.Client {
 	client, err := event.NewClient(log, cfg.GetGRPCAddress())
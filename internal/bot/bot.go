@@ -1,42 +1,77 @@
 package bot
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/dispatcher"
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/handlers"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/middleware"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/service"
 	tele "gopkg.in/telebot.v3"
 )
 
 // Bot описывает телеграм-бота
 type Bot struct {
-	log     *slog.Logger
-	bot     *tele.Bot
-	handler *handlers.Handler
+	log      *slog.Logger
+	bot      *tele.Bot
+	handler  *handlers.Handler
+	profiles *middleware.ProfileBatcher
+	limiter  *middleware.RateLimiter
+	updates  *dispatcher.Dispatcher
+}
+
+// Settings перезагружаемые настройки обработчиков
+type Settings = handlers.Settings
+
+// Options параметры бота
+type Options struct {
+	Token string
+	// PollerTimeout таймаут long polling запросов к Telegram
+	PollerTimeout time.Duration
+	// Workers число обработчиков входящих обновлений
+	Workers int
+	// RequestTimeout ограничение времени обработки одного обновления
+	RequestTimeout time.Duration
+	// ProfileFlushInterval период пакетного сохранения профилей пользователей
+	ProfileFlushInterval time.Duration
+	// ProfileFlushTimeout ограничение времени одного сохранения профилей
+	ProfileFlushTimeout time.Duration
+	// Settings начальные значения перезагружаемых настроек обработчиков
+	Settings Settings
 }
 
 // NewBot конструктор для Bot
-func NewBot(log *slog.Logger, token string, service *service.Service) (*Bot, error) {
+func NewBot(log *slog.Logger, service *service.Service, limiter *middleware.RateLimiter, opts Options) (*Bot, error) {
+	updates := dispatcher.NewDispatcher(log, &tele.LongPoller{Timeout: opts.PollerTimeout}, opts.Workers)
 	b, err := tele.NewBot(tele.Settings{
-		Token:  token,
-		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
+		Token:  opts.Token,
+		Poller: updates,
+		// Обработчики выполняются в пуле Dispatcher, а не в отдельной горутине на каждое обновление
+		Synchronous: true,
 	})
 	if err != nil {
-		return nil, err
+		// Текст ошибки telebot может содержать URL запроса с токеном бота
+		return nil, logger.ScrubError(err)
 	}
 
-	h := handlers.NewHandler(log, service, b)
+	h := handlers.NewHandler(log, service, b, handlers.Options{Timeout: opts.RequestTimeout, Settings: opts.Settings})
 
 	return &Bot{
-		log:     log,
-		bot:     b,
-		handler: h,
+		log:      log,
+		bot:      b,
+		handler:  h,
+		profiles: middleware.NewProfileBatcher(log, service, opts.ProfileFlushInterval, opts.ProfileFlushTimeout),
+		limiter:  limiter,
+		updates:  updates,
 	}, nil
 }
 
-// MustStart запускает бота, в прослойке с помощью recover отлавливает паники, регистрирует обработчики
-func (b *Bot) MustStart() {
+// MustStart запускает бота с корневым контекстом ctx, в прослойке с помощью recover отлавливает паники, ограничивает частоту обновлений, регистрирует обработчики,
+// запускает пакетное сохранение профилей пользователей
+func (b *Bot) MustStart(ctx context.Context) {
 	b.bot.Use(func(next tele.HandlerFunc) tele.HandlerFunc {
 		return func(c tele.Context) error {
 			defer func() {
@@ -47,12 +82,70 @@ func (b *Bot) MustStart() {
 			return next(c)
 		}
 	})
+	b.bot.Use(b.limiter.Middleware)
+	b.bot.Use(b.profiles.Middleware)
 
-	b.handler.RegisterHandlers(b.bot)
+	go b.profiles.Start(ctx)
+
+	b.handler.RegisterHandlers(ctx, b.bot)
 	b.bot.Start()
 }
 
-// Stop останавливает бота
-func (b *Bot) Stop() {
-	b.bot.Stop()
+// Stop прекращает получение обновлений, дожидается завершения уже запущенных обработчиков,
+// но не дольше дедлайна ctx, и сохраняет накопленные профили пользователей.
+// Возвращает ошибку, если обработчики не завершились вовремя
+func (b *Bot) Stop(ctx context.Context) error {
+	// Остановка получения обновлений тоже ограничена дедлайном: telebot ждёт возврата Dispatcher.Poll
+	stopped := make(chan struct{})
+	go func() {
+		b.bot.Stop()
+		close(stopped)
+	}()
+
+	var err error
+	select {
+	case <-stopped:
+		err = b.updates.Drain(ctx)
+	case <-ctx.Done():
+		b.log.Warn("update polling did not stop before deadline")
+		err = dispatcher.ErrDrainTimeout
+	}
+	b.profiles.Stop()
+	return err
+}
+
+// UpdateSettings применяет перезагруженные настройки обработчиков: списки администраторов и сотрудников,
+// размер страницы списка событий, включение поиска и приветствие
+func (b *Bot) UpdateSettings(s Settings) {
+	b.handler.UpdateSettings(s)
+}
+
+// SetRateLimits применяет перезагруженные лимиты частоты обновлений
+func (b *Bot) SetRateLimits(userRate float64, userBurst int, globalRate float64, globalBurst, banThreshold int, banDuration time.Duration) {
+	b.limiter.SetLimits(userRate, userBurst, globalRate, globalBurst, banThreshold, banDuration)
+}
+
+// SendFeedbackSurveys рассылает опросы участникам завершившихся событий
+func (b *Bot) SendFeedbackSurveys(ctx context.Context) {
+	b.handler.SendFeedbackSurveys(ctx)
+}
+
+// NotifyEventChanges рассылает зарегистрированным пользователям уведомления об изменённых и отменённых событиях
+func (b *Bot) NotifyEventChanges(ctx context.Context) {
+	b.handler.NotifyEventChanges(ctx)
+}
+
+// AnnounceNewEvents рассылает подписчикам анонсы новых событий
+func (b *Bot) AnnounceNewEvents(ctx context.Context) {
+	b.handler.AnnounceNewEvents(ctx)
+}
+
+// SetBroadcastRate применяет допустимое число сообщений в секунду для рассылок
+func (b *Bot) SetBroadcastRate(rate float64) {
+	b.handler.SetBroadcastRate(rate)
+}
+
+// DeliverQueuedRegistrations отправляет отложенные заявки на регистрацию и сообщает пользователям результат
+func (b *Bot) DeliverQueuedRegistrations(ctx context.Context) {
+	b.handler.DeliverQueuedRegistrations(ctx)
 }
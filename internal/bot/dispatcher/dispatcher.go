@@ -0,0 +1,147 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// queueSize размер очереди обновлений одного обработчика
+const queueSize = 64
+
+// ErrDrainTimeout возвращается, если обработчики не завершились до истечения дедлайна
+var ErrDrainTimeout = errors.New("update handlers did not finish in time")
+
+// Dispatcher обёртка над Poller, которая распределяет обновления между фиксированным числом обработчиков.
+// Обновления одного чата всегда попадают к одному и тому же обработчику, поэтому обрабатываются по порядку.
+// Бот должен быть создан с Settings.Synchronous, чтобы обработчики выполнялись внутри пула, а не в отдельных горутинах
+type Dispatcher struct {
+	log     *slog.Logger
+	poller  tele.Poller
+	workers int
+
+	wg sync.WaitGroup
+	// done закрывается, когда получение обновлений остановлено и все очереди закрыты
+	done    chan struct{}
+	started chan struct{}
+	once    sync.Once
+}
+
+// NewDispatcher конструктор для Dispatcher
+func NewDispatcher(log *slog.Logger, poller tele.Poller, workers int) *Dispatcher {
+	return &Dispatcher{
+		log:     log,
+		poller:  poller,
+		workers: workers,
+		done:    make(chan struct{}),
+		started: make(chan struct{}),
+	}
+}
+
+// Poll реализует tele.Poller: получает обновления от вложенного Poller и передаёт их обработчикам.
+// Возвращается после остановки вложенного Poller, не дожидаясь завершения обработки
+func (d *Dispatcher) Poll(b *tele.Bot, _ chan tele.Update, stop chan struct{}) {
+	d.once.Do(func() { close(d.started) })
+
+	queues := make([]chan tele.Update, d.workers)
+	for i := range queues {
+		queues[i] = make(chan tele.Update, queueSize)
+		d.wg.Add(1)
+		go d.work(b, queues[i])
+	}
+
+	incoming := make(chan tele.Update)
+	polled := make(chan struct{})
+	go func() {
+		d.poller.Poll(b, incoming, stop)
+		close(polled)
+	}()
+
+	defer func() {
+		for _, q := range queues {
+			close(q)
+		}
+		close(d.done)
+	}()
+
+	for {
+		select {
+		case u := <-incoming:
+			d.enqueue(queues[d.shard(b, u)], u, stop)
+		case <-polled:
+			return
+		}
+	}
+}
+
+// enqueue передаёт обновление обработчику. Если его очередь заполнена медленным обработчиком, а бот
+// останавливается, обновление отбрасывается, чтобы остановка не ждала очередь: Telegram не получил
+// подтверждения следующим запросом getUpdates и доставит обновление снова после перезапуска
+func (d *Dispatcher) enqueue(queue chan<- tele.Update, u tele.Update, stop <-chan struct{}) {
+	// Пока в очереди есть место, обновление ставится в неё и во время остановки
+	select {
+	case queue <- u:
+		return
+	default:
+	}
+
+	select {
+	case queue <- u:
+	case <-stop:
+		d.log.Warn("update dropped on shutdown", slog.Int("update_id", u.ID))
+	}
+}
+
+// work последовательно обрабатывает обновления из очереди
+func (d *Dispatcher) work(b *tele.Bot, queue <-chan tele.Update) {
+	defer d.wg.Done()
+	for u := range queue {
+		b.ProcessUpdate(u)
+	}
+}
+
+// shard выбирает обработчик для обновления по идентификатору чата, а при его отсутствии - по отправителю
+func (d *Dispatcher) shard(b *tele.Bot, u tele.Update) int {
+	c := b.NewContext(u)
+
+	var key int64
+	switch {
+	case c.Chat() != nil:
+		key = c.Chat().ID
+	case c.Sender() != nil:
+		key = c.Sender().ID
+	}
+	if key < 0 {
+		key = -key
+	}
+	return int(key % int64(d.workers))
+}
+
+// Drain дожидается завершения обработки уже полученных обновлений, но не дольше дедлайна ctx.
+// Должен вызываться после остановки бота, иначе будет ждать до истечения ctx
+func (d *Dispatcher) Drain(ctx context.Context) error {
+	select {
+	case <-d.started:
+	default:
+		return nil
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		<-d.done
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		d.log.Info("update handlers drained")
+		return nil
+	case <-ctx.Done():
+		d.log.Warn("update handlers did not finish before deadline")
+		return ErrDrainTimeout
+	}
+}
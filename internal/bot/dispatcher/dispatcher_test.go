@@ -0,0 +1,196 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// slicePoller отдаёт заранее заданные обновления и ждёт остановки
+type slicePoller struct {
+	updates []tele.Update
+	sent    chan struct{}
+	// pushed количество обновлений, принятых Dispatcher
+	pushed atomic.Int32
+}
+
+func (p *slicePoller) Poll(_ *tele.Bot, dest chan tele.Update, stop chan struct{}) {
+	for _, u := range p.updates {
+		select {
+		case dest <- u:
+			p.pushed.Add(1)
+		case <-stop:
+			return
+		}
+	}
+	close(p.sent)
+	<-stop
+}
+
+// newTestBot создаёт бота без подключения к Telegram, обрабатывающего обновления синхронно
+func newTestBot(t *testing.T) *tele.Bot {
+	t.Helper()
+	b, err := tele.NewBot(tele.Settings{Offline: true, Synchronous: true})
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+	return b
+}
+
+func TestDispatcherKeepsPerChatOrder(t *testing.T) {
+	chats := []int64{1, 2, 3, 42, -1001234567890, 7}
+	const perChat = 30
+
+	tests := []struct {
+		name    string
+		workers int
+	}{
+		{name: "single worker", workers: 1},
+		{name: "fewer workers than chats", workers: 3},
+		{name: "more workers than chats", workers: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+
+			var (
+				mu      sync.Mutex
+				handled sync.WaitGroup
+			)
+			got := make(map[int64][]int)
+			handled.Add(len(chats) * perChat)
+			b.Handle(tele.OnText, func(c tele.Context) error {
+				defer handled.Done()
+				// Короткая пауза даёт обновлениям разных чатов перемешаться между обработчиками
+				time.Sleep(time.Duration(c.Message().ID%3) * 100 * time.Microsecond)
+				mu.Lock()
+				got[c.Chat().ID] = append(got[c.Chat().ID], c.Message().ID)
+				mu.Unlock()
+				return nil
+			})
+
+			var updates []tele.Update
+			for i := range perChat {
+				for _, chatID := range chats {
+					updates = append(updates, tele.Update{Message: &tele.Message{
+						ID:     i,
+						Text:   "text",
+						Chat:   &tele.Chat{ID: chatID},
+						Sender: &tele.User{ID: chatID},
+					}})
+				}
+			}
+
+			poller := &slicePoller{updates: updates, sent: make(chan struct{})}
+			d := NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), poller, tt.workers)
+			stop := make(chan struct{})
+			go d.Poll(b, nil, stop)
+
+			// Остановка раньше времени отбросила бы обновления, ждущие места в заполненной очереди
+			handled.Wait()
+			close(stop)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := d.Drain(ctx); err != nil {
+				t.Fatalf("drain: %v", err)
+			}
+
+			for _, chatID := range chats {
+				ids := got[chatID]
+				if len(ids) != perChat {
+					t.Fatalf("chat %d: got %d updates, want %d", chatID, len(ids), perChat)
+				}
+				for i, id := range ids {
+					if id != i {
+						t.Fatalf("chat %d: got order %v", chatID, ids)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcherDrain(t *testing.T) {
+	t.Run("not started", func(t *testing.T) {
+		d := NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), &slicePoller{sent: make(chan struct{})}, 2)
+		if err := d.Drain(context.Background()); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	})
+
+	t.Run("handler outlives deadline", func(t *testing.T) {
+		b := newTestBot(t)
+		release := make(chan struct{})
+		defer close(release)
+		b.Handle(tele.OnText, func(tele.Context) error {
+			<-release
+			return nil
+		})
+
+		poller := &slicePoller{
+			updates: []tele.Update{{Message: &tele.Message{Text: "text", Chat: &tele.Chat{ID: 1}}}},
+			sent:    make(chan struct{}),
+		}
+		d := NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), poller, 2)
+		stop := make(chan struct{})
+		go d.Poll(b, nil, stop)
+
+		<-poller.sent
+		close(stop)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := d.Drain(ctx); !errors.Is(err, ErrDrainTimeout) {
+			t.Fatalf("got %v, want %v", err, ErrDrainTimeout)
+		}
+	})
+}
+
+func TestDispatcherPollStopsWithFullQueue(t *testing.T) {
+	b := newTestBot(t)
+	release := make(chan struct{})
+	defer close(release)
+	b.Handle(tele.OnText, func(tele.Context) error {
+		<-release
+		return nil
+	})
+
+	// Один обработчик занят первым обновлением, очередь заполнена, и ещё одно обновление ждёт места в ней
+	updates := make([]tele.Update, queueSize+3)
+	for i := range updates {
+		updates[i] = tele.Update{ID: i, Message: &tele.Message{ID: i, Text: "text", Chat: &tele.Chat{ID: 1}}}
+	}
+	poller := &slicePoller{updates: updates, sent: make(chan struct{})}
+	d := NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), poller, 1)
+
+	stop := make(chan struct{})
+	returned := make(chan struct{})
+	go func() {
+		d.Poll(b, nil, stop)
+		close(returned)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for poller.pushed.Load() < queueSize+2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("dispatcher accepted only %d updates", poller.pushed.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Poll did not return after stop while the queue was full")
+	}
+}
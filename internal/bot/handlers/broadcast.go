@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// broadcaster ограничивает частоту рассылок многим пользователям, чтобы не упираться в лимиты Telegram Bot API
+type broadcaster struct {
+	mu sync.Mutex
+	// interval минимальный промежуток между сообщениями, 0 - без ограничения
+	interval time.Duration
+	// next время, начиная с которого можно отправить следующее сообщение
+	next time.Time
+}
+
+// setRate задаёт допустимое число сообщений в секунду
+func (b *broadcaster) setRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if rate <= 0 {
+		b.interval = 0
+		return
+	}
+	b.interval = time.Duration(float64(time.Second) / rate)
+}
+
+// wait занимает очередь на отправку сообщения и ждёт её, пока не отменён контекст
+func (b *broadcaster) wait(ctx context.Context) error {
+	b.mu.Lock()
+	at := time.Now()
+	if b.next.After(at) {
+		at = b.next
+	}
+	b.next = at.Add(b.interval)
+	b.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause откладывает все следующие отправки не меньше чем на d
+func (b *broadcaster) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.next) {
+		b.next = until
+	}
+}
+
+// SetBroadcastRate задаёт допустимое число сообщений в секунду для рассылок
+func (h *Handler) SetBroadcastRate(rate float64) {
+	h.broadcaster.setRate(rate)
+	h.log.Info("broadcast rate updated", slog.Float64("rate", rate))
+}
+
+// broadcast отправляет сообщение одному из получателей рассылки с ограничением частоты.
+// Если Telegram просит подождать, вся рассылка приостанавливается и сообщение отправляется повторно
+func (h *Handler) broadcast(ctx context.Context, chatID int64, what interface{}, opts ...interface{}) error {
+	for retried := false; ; retried = true {
+		if err := h.broadcaster.wait(ctx); err != nil {
+			return err
+		}
+		err := h.notify(ctx, chatID, what, opts...)
+		var flood tele.FloodError
+		if retried || !errors.As(err, &flood) {
+			return err
+		}
+		h.log.WarnContext(ctx, "broadcast throttled by telegram", slog.Int("retry_after", flood.RetryAfter))
+		h.broadcaster.pause(time.Duration(flood.RetryAfter) * time.Second)
+	}
+}
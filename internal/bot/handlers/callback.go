@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// callbackDedupWindow время, в течение которого повторный callback с тем же идентификатором игнорируется
+	callbackDedupWindow = time.Minute
+	// actionDedupWindow время, в течение которого повторное действие на том же сообщении игнорируется.
+	// Каждое нажатие получает новый идентификатор callback-запроса, а обработчик заменяет кнопки сообщения
+	// только после ответа, поэтому второе нажатие до этого момента отсекается по сообщению
+	actionDedupWindow = 10 * time.Minute
+)
+
+// callbackGuard защищает от повторной обработки callback'ов: отбрасывает уже обработанные идентификаторы
+// callback-запросов и не даёт одновременно обрабатывать несколько нажатий на одно сообщение
+type callbackGuard struct {
+	mu sync.Mutex
+	// seen идентификаторы обработанных callback-запросов и время их получения
+	seen map[string]time.Time
+	// busy сообщения, нажатие на кнопки которых сейчас обрабатывается
+	busy map[string]struct{}
+	// actions действия, уже выполненные или выполняемые на сообщениях, и время их начала
+	actions   map[string]time.Time
+	lastSweep time.Time
+}
+
+// newCallbackGuard конструктор для callbackGuard
+func newCallbackGuard() *callbackGuard {
+	return &callbackGuard{
+		seen:      make(map[string]time.Time),
+		busy:      make(map[string]struct{}),
+		actions:   make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// seenBefore запоминает идентификатор callback-запроса и сообщает, встречался ли он в течение callbackDedupWindow
+func (g *callbackGuard) seenBefore(callbackID string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.sweep(now)
+
+	if at, ok := g.seen[callbackID]; ok && now.Sub(at) <= callbackDedupWindow {
+		return true
+	}
+	g.seen[callbackID] = now
+	return false
+}
+
+// sweep удаляет устаревшие записи, вызывается под g.mu
+func (g *callbackGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) <= callbackDedupWindow {
+		return
+	}
+	for id, at := range g.seen {
+		if now.Sub(at) > callbackDedupWindow {
+			delete(g.seen, id)
+		}
+	}
+	for key, at := range g.actions {
+		if now.Sub(at) > actionDedupWindow {
+			delete(g.actions, key)
+		}
+	}
+	g.lastSweep = now
+}
+
+// claimAction отмечает начало действия action на сообщении messageID в чате chatID.
+// Возвращает false, если это действие на сообщении уже выполнялось в течение actionDedupWindow
+func (g *callbackGuard) claimAction(action string, chatID int64, messageID int, now time.Time) bool {
+	key := messageKey(chatID, messageID) + ":" + action
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.sweep(now)
+	if at, ok := g.actions[key]; ok && now.Sub(at) <= actionDedupWindow {
+		return false
+	}
+	g.actions[key] = now
+	return true
+}
+
+// releaseAction снимает отметку действия, например если оно не удалось и его можно повторить
+func (g *callbackGuard) releaseAction(action string, chatID int64, messageID int) {
+	g.mu.Lock()
+	delete(g.actions, messageKey(chatID, messageID)+":"+action)
+	g.mu.Unlock()
+}
+
+// messageKey ключ сообщения messageID в чате chatID
+func messageKey(chatID int64, messageID int) string {
+	return strconv.FormatInt(chatID, 10) + ":" + strconv.Itoa(messageID)
+}
+
+// lock захватывает сообщение messageID в чате chatID. Возвращает функцию освобождения,
+// либо false, если нажатие на это сообщение уже обрабатывается
+func (g *callbackGuard) lock(chatID int64, messageID int) (func(), bool) {
+	key := messageKey(chatID, messageID)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.busy[key]; ok {
+		return nil, false
+	}
+	g.busy[key] = struct{}{}
+	return func() {
+		g.mu.Lock()
+		delete(g.busy, key)
+		g.mu.Unlock()
+	}, true
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// fakeService сервисный слой для тестов обработчиков, неиспользуемые методы не реализованы
+type fakeService struct {
+	Service
+
+	mu        sync.Mutex
+	registers []models.RegistrationRequest
+	checkIns  []string
+	comments  []string
+}
+
+func (s *fakeService) RegisterUser(_ context.Context, req models.RegistrationRequest) (models.RegistrationOutcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registers = append(s.registers, req)
+	return models.RegistrationConfirmed, nil
+}
+
+func (s *fakeService) CheckIn(_ context.Context, code string, staffChatID int64) (models.CheckIn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkIns = append(s.checkIns, code)
+	return models.CheckIn{ChatID: 2, CheckedInBy: staffChatID}, nil
+}
+
+func (s *fakeService) CommentEvent(_ context.Context, _ string, _ int64, comment string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.comments = append(s.comments, comment)
+	return nil
+}
+
+func (s *fakeService) DeleteUserData(context.Context, int64) (bool, error) {
+	return false, nil
+}
+
+func (s *fakeService) IssueTicket(context.Context, string, int64) (string, error) {
+	return "", models.ErrTicketsDisabled
+}
+
+// fakeTelegram имитирует Bot API и запоминает вызванные методы
+type fakeTelegram struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (f *fakeTelegram) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.mu.Lock()
+	f.methods = append(f.methods, method)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasPrefix(method, "send") || strings.HasPrefix(method, "edit") {
+		_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":10,"chat":{"id":1,"type":"private"}}}`)
+		return
+	}
+	_, _ = io.WriteString(w, `{"ok":true,"result":true}`)
+}
+
+// count возвращает, сколько раз был вызван метод Bot API
+func (f *fakeTelegram) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, m := range f.methods {
+		if m == method {
+			n++
+		}
+	}
+	return n
+}
+
+// newTestHandler создаёт обработчик с ботом, подключённым к имитации Bot API
+func newTestHandler(t *testing.T, svc Service) (*Handler, *tele.Bot, *fakeTelegram) {
+	t.Helper()
+	api := &fakeTelegram{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	b, err := tele.NewBot(tele.Settings{URL: srv.URL, Token: "test", Offline: true, Synchronous: true})
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+
+	h := NewHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), svc, b, Options{
+		Timeout:  time.Second,
+		Settings: Settings{PageSize: 5, WelcomeMessage: "Привет"},
+	})
+	h.RegisterHandlers(context.Background(), b)
+	return h, b, api
+}
+
+// textUpdate текстовое сообщение text от пользователя userID в личном чате
+func textUpdate(userID int64, text string) tele.Update {
+	return tele.Update{Message: &tele.Message{
+		Text:   text,
+		Chat:   &tele.Chat{ID: userID, Type: tele.ChatPrivate},
+		Sender: &tele.User{ID: userID},
+	}}
+}
+
+// callbackUpdate нажатие кнопки с данными data на сообщении messageID
+func callbackUpdate(id string, messageID int, data string) tele.Update {
+	chat := &tele.Chat{ID: 1, Type: tele.ChatPrivate}
+	return tele.Update{Callback: &tele.Callback{
+		ID:      id,
+		Sender:  &tele.User{ID: 1, Username: "user"},
+		Message: &tele.Message{ID: messageID, Chat: chat},
+		Data:    data,
+	}}
+}
+
+func TestRegisterIgnoresRepeatedTapOnSameMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		taps      []tele.Update
+		wantCalls int
+	}{
+		{
+			name: "double tap on one message",
+			taps: []tele.Update{
+				callbackUpdate("cb-1", 5, "register:event-1"),
+				callbackUpdate("cb-2", 5, "register:event-1"),
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "redelivered callback",
+			taps: []tele.Update{
+				callbackUpdate("cb-1", 5, "register:event-1"),
+				callbackUpdate("cb-1", 5, "register:event-1"),
+			},
+			wantCalls: 1,
+		},
+		{
+			name: "different messages",
+			taps: []tele.Update{
+				callbackUpdate("cb-1", 5, "register:event-1"),
+				callbackUpdate("cb-2", 6, "register:event-1"),
+			},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeService{}
+			_, b, api := newTestHandler(t, svc)
+
+			for _, u := range tt.taps {
+				b.ProcessUpdate(u)
+			}
+
+			if got := len(svc.registers); got != tt.wantCalls {
+				t.Errorf("RegisterUser called %d times, want %d", got, tt.wantCalls)
+			}
+			if got := api.count("editMessageText"); got != tt.wantCalls {
+				t.Errorf("message edited %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
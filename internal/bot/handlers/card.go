@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxCaptionLength ограничение Telegram на длину подписи к фото
+const maxCaptionLength = 1024
+
+// sendPhotoCard отправляет карточку события в виде фото с подписью.
+// Сообщение со списком событий нельзя отредактировать в фото, поэтому оно удаляется
+func (h *Handler) sendPhotoCard(c tele.Context, photo, caption string, markup *tele.ReplyMarkup) error {
+	if c.Callback() != nil {
+		if err := c.Delete(); err != nil {
+			h.log.WarnContext(h.logContext(c), "failed to delete events list", logger.Err(err))
+		}
+	}
+
+	if utf8.RuneCountInString(caption) > maxCaptionLength {
+		caption = string([]rune(caption)[:maxCaptionLength-1]) + "…"
+	}
+
+	file := tele.File{FileID: photo}
+	if strings.HasPrefix(photo, "http://") || strings.HasPrefix(photo, "https://") {
+		file = tele.FromURL(photo)
+	}
+
+	opts := &tele.SendOptions{ParseMode: tele.ModeMarkdown, ReplyMarkup: markup}
+	if err := c.Send(&tele.Photo{File: file, Caption: caption}, opts); err != nil {
+		h.log.ErrorContext(h.logContext(c), "failed to send photo card", logger.Err(err))
+		return c.Send(caption, opts)
+	}
+	return nil
+}
+
+// sendEventLocation отправляет место проведения события: точку на карте, если заданы координаты, иначе адрес
+func (h *Handler) sendEventLocation(c tele.Context, eventID string) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	event, err := h.service.GetEvent(ctx, eventID)
+	if err != nil || event == nil {
+		return h.showEvents(c, 0)
+	}
+
+	extras, err := h.service.GetEventExtras(ctx, event)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get event extras", logger.Err(err))
+		return c.Send("Не удалось получить место проведения.")
+	}
+
+	if extras.HasLocation() {
+		return c.Send(&tele.Venue{
+			Location: tele.Location{Lat: float32(*extras.Latitude), Lng: float32(*extras.Longitude)},
+			Title:    event.GetTitle(),
+			Address:  extras.Address,
+		})
+	}
+	if extras.Address != "" {
+		return c.Send("📍 " + extras.Address)
+	}
+	return c.Send("Место проведения не указано.")
+}
+
+// eventInfo обработчик для команды /eventinfo <event_id> поле=значение; ..., доступен только администраторам.
+// Задаёт обложку (photo), адрес (address), координаты (location) и ссылку (url) события
+func (h *Handler) eventInfo(c tele.Context) error {
+	if !h.isAdmin(c) {
+		return nil
+	}
+
+	eventID, rest, _ := strings.Cut(strings.TrimSpace(c.Message().Payload), " ")
+	if eventID == "" || strings.TrimSpace(rest) == "" {
+		return c.Send("Использование: /eventinfo <event_id> photo=<ссылка>; address=<адрес>; location=<широта>,<долгота>; url=<ссылка>\nЗначение \"-\" очищает поле.")
+	}
+
+	fields := make(map[string]string)
+	for _, pair := range strings.Split(rest, ";") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		fields[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	if _, err := h.service.UpdateEventExtras(ctx, eventID, fields); err != nil {
+		switch {
+		case errors.Is(err, models.ErrUnknownEventField):
+			return c.Send("Неизвестное поле. Доступные поля: photo, address, location, url.")
+		case errors.Is(err, models.ErrInvalidLocation):
+			return c.Send("Координаты указываются как <широта>,<долгота>, например location=55.7539,37.6208.")
+		case errors.Is(err, models.ErrInvalidURL):
+			return c.Send("Ссылка должна начинаться с http:// или https://.")
+		}
+		h.log.ErrorContext(ctx, "failed to update event extras", logger.Err(err))
+		return c.Send("Не удалось сохранить информацию о событии, попробуйте позже.")
+	}
+	return c.Send("Информация о событии обновлена.")
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/ticket"
+	"github.com/skip2/go-qrcode"
+	tele "gopkg.in/telebot.v3"
+)
+
+// sendTicket отправляет участнику QR-код с подписанным билетом на событие.
+// Принимает чат, а не контекст обновления, так как билет отправляется и после подтверждения отложенной заявки
+func (h *Handler) sendTicket(ctx context.Context, chatID int64, eventID string) error {
+	code, err := h.service.IssueTicket(ctx, eventID, chatID)
+	if err != nil {
+		if !errors.Is(err, models.ErrTicketsDisabled) {
+			h.log.ErrorContext(ctx, "failed to issue ticket", logger.Err(err))
+		}
+		return nil
+	}
+
+	png, err := qrcode.Encode(code, qrcode.Medium, 512)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to render ticket qr-code", logger.Err(err))
+		return nil
+	}
+
+	return h.notify(ctx, chatID, &tele.Photo{
+		File: tele.FromReader(bytes.NewReader(png)),
+		Caption: fmt.Sprintf(
+			"Ваш билет на событие. Покажите QR-код на входе.\n\nКод билета:\n`%s`",
+			code,
+		),
+	}, &tele.SendOptions{ParseMode: tele.ModeMarkdown})
+}
+
+// checkIn обработчик для команды /checkin [код билета], доступен сотрудникам и администраторам.
+// С кодом билета - сразу отмечает посещение, без него - включает или выключает режим отметки,
+// в котором каждое текстовое сообщение считается кодом билета
+func (h *Handler) checkIn(c tele.Context) error {
+	if !h.isStaff(c) {
+		return nil
+	}
+
+	if code := c.Message().Payload; code != "" {
+		return h.checkInTicket(c, code)
+	}
+
+	chatID := c.Chat().ID
+
+	h.mu.Lock()
+	_, enabled := h.checkInMode[chatID]
+	if enabled {
+		delete(h.checkInMode, chatID)
+	} else {
+		h.checkInMode[chatID] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	if enabled {
+		return c.Send("Режим отметки посещения выключен.")
+	}
+	return c.Send("Режим отметки посещения включён. Отсканируйте QR-код билета и отправьте его код сообщением. Чтобы выйти, снова отправьте /checkin.")
+}
+
+// inCheckInMode проверяет, включён ли в чате режим отметки посещения
+func (h *Handler) inCheckInMode(chatID int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.checkInMode[chatID]
+	return ok
+}
+
+// checkInTicket проверяет код билета и отмечает посещение события
+func (h *Handler) checkInTicket(c tele.Context, code string) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	checkIn, err := h.service.CheckIn(ctx, code, c.Sender().ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ticket.ErrInvalidSignature), errors.Is(err, ticket.ErrMalformed):
+			return c.Send("❌ Недействительный билет.")
+		case errors.Is(err, models.ErrRegistrationNotFound):
+			return c.Send("❌ Владелец билета не зарегистрирован на событие.")
+		case errors.Is(err, models.ErrTicketRevoked):
+			return c.Send("❌ Билет заменён более новым, попросите показать последний полученный билет.")
+		case errors.Is(err, models.ErrTicketsDisabled):
+			return c.Send("Билеты отключены.")
+		}
+		h.log.ErrorContext(ctx, "failed to check in", logger.Err(err))
+		return c.Send("Произошла ошибка.")
+	}
+
+	who := fmt.Sprintf("chat_id %d", checkIn.ChatID)
+	if checkIn.Username != "" {
+		who = "@" + checkIn.Username
+	}
+
+	if checkIn.Duplicate {
+		return c.Send(fmt.Sprintf(
+			"⚠️ Повторное сканирование: %s уже отмечен в %s.",
+			who, checkIn.CheckedInAt.Format("02.01.2006 15:04"),
+		))
+	}
+	return c.Send(fmt.Sprintf("✅ %s отмечен на событии.", who))
+}
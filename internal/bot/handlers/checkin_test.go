@@ -0,0 +1,50 @@
+package handlers
+
+import "testing"
+
+func TestCheckInModeRequiresStaff(t *testing.T) {
+	const staffID = 1
+
+	tests := []struct {
+		name string
+		// demote исключает сотрудника из списка после включения режима отметки
+		demote bool
+		// bypass имитирует режим, оставшийся у исключённого сотрудника, без сброса в UpdateSettings
+		bypass    bool
+		wantCalls int
+	}{
+		{name: "staff scans ticket", wantCalls: 1},
+		{name: "removed from staff", demote: true},
+		{name: "stale mode of removed staff", demote: true, bypass: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeService{}
+			h, b, _ := newTestHandler(t, svc)
+			h.UpdateSettings(Settings{StaffIDs: []int64{staffID}, PageSize: 5})
+
+			b.ProcessUpdate(textUpdate(staffID, "/checkin"))
+			if !h.inCheckInMode(staffID) {
+				t.Fatal("check-in mode was not enabled")
+			}
+
+			if tt.demote {
+				h.UpdateSettings(Settings{PageSize: 5})
+				if h.inCheckInMode(staffID) {
+					t.Error("check-in mode kept after removal from staff")
+				}
+			}
+			if tt.bypass {
+				h.mu.Lock()
+				h.checkInMode[staffID] = struct{}{}
+				h.mu.Unlock()
+			}
+
+			b.ProcessUpdate(textUpdate(staffID, "ticket-code"))
+			if got := len(svc.checkIns); got != tt.wantCalls {
+				t.Errorf("CheckIn called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	tele "gopkg.in/telebot.v3"
+)
+
+// Role описывает уровень доступа пользователя к командам бота
+type Role int
+
+// Уровни доступа, каждый следующий включает предыдущие
+const (
+	RoleUser Role = iota
+	RoleStaff
+	RoleAdmin
+)
+
+// Языки, для которых публикуется меню команд. Пустой код - язык по умолчанию
+const (
+	langDefault = ""
+	langEN      = "en"
+)
+
+// commandLanguages языки меню команд
+var commandLanguages = []string{langDefault, langEN}
+
+// Command описывает команду бота
+type Command struct {
+	// Name название команды без "/"
+	Name string
+	// Description описание команды по кодам языков, langDefault - описание на русском языке
+	Description map[string]string
+	Handler     tele.HandlerFunc
+	// Role минимальный уровень доступа, необходимый для вызова команды
+	Role Role
+}
+
+// description возвращает описание команды на языке пользователя, при его отсутствии - на языке по умолчанию
+func (cmd Command) description(lang string) string {
+	if d, ok := cmd.Description[lang]; ok {
+		return d
+	}
+	return cmd.Description[langDefault]
+}
+
+// commands возвращает реестр команд бота
+func (h *Handler) commands() []Command {
+	return []Command{
+		{Name: "start", Handler: h.startMessage, Role: RoleUser, Description: map[string]string{
+			langDefault: "Начать работу с ботом",
+			langEN:      "Start the bot",
+		}},
+		{Name: "help", Handler: h.help, Role: RoleUser, Description: map[string]string{
+			langDefault: "Список доступных команд",
+			langEN:      "List available commands",
+		}},
+		{Name: "timezone", Handler: h.setTimezone, Role: RoleUser, Description: map[string]string{
+			langDefault: "Указать часовой пояс, например /timezone Europe/Moscow",
+			langEN:      "Set your timezone, e.g. /timezone Europe/London",
+		}},
+		{Name: "subscribe", Handler: h.subscribe, Role: RoleUser, Description: map[string]string{
+			langDefault: "Анонсы новых событий, например /subscribe концерт, джаз",
+			langEN:      "New event announcements, e.g. /subscribe concert, jazz",
+		}},
+		{Name: "mydata", Handler: h.myData, Role: RoleUser, Description: map[string]string{
+			langDefault: "Выгрузить мои данные",
+			langEN:      "Export my data",
+		}},
+		{Name: "deleteme", Handler: h.deleteMe, Role: RoleUser, Description: map[string]string{
+			langDefault: "Удалить мои данные",
+			langEN:      "Delete my data",
+		}},
+		{Name: "checkin", Handler: h.checkIn, Role: RoleStaff, Description: map[string]string{
+			langDefault: "Режим отметки посещения по билетам",
+			langEN:      "Ticket check-in mode",
+		}},
+		{Name: "stats", Handler: h.stats, Role: RoleAdmin, Description: map[string]string{
+			langDefault: "Статистика пользователей",
+			langEN:      "User statistics",
+		}},
+		{Name: "participants", Handler: h.participants, Role: RoleAdmin, Description: map[string]string{
+			langDefault: "Выгрузить участников события в CSV",
+			langEN:      "Export event participants as CSV",
+		}},
+		{Name: "feedback", Handler: h.feedbackSummary, Role: RoleAdmin, Description: map[string]string{
+			langDefault: "Отзывы о событии",
+			langEN:      "Event feedback summary",
+		}},
+		{Name: "eventinfo", Handler: h.eventInfo, Role: RoleAdmin, Description: map[string]string{
+			langDefault: "Задать обложку, место и ссылку события",
+			langEN:      "Set event cover, venue and link",
+		}},
+	}
+}
+
+// registerCommands регистрирует обработчики всех команд из реестра с проверкой уровня доступа
+func (h *Handler) registerCommands(b *tele.Bot) {
+	for _, cmd := range h.commands() {
+		b.Handle("/"+cmd.Name, h.requireRole(cmd.Role, h.withOperation("command."+cmd.Name, cmd.Handler)))
+	}
+}
+
+// withOperation прослойка, указывающая в контексте обновления обрабатываемую команду
+func (h *Handler) withOperation(op string, next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		h.setOperation(c, op)
+		return next(c)
+	}
+}
+
+// requireRole прослойка, игнорирующая команду, если у пользователя недостаточно прав
+func (h *Handler) requireRole(role Role, next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		if h.role(c) < role {
+			return nil
+		}
+		return next(c)
+	}
+}
+
+// role возвращает уровень доступа отправителя обновления
+func (h *Handler) role(c tele.Context) Role {
+	switch {
+	case h.isAdmin(c):
+		return RoleAdmin
+	case h.isStaff(c):
+		return RoleStaff
+	}
+	return RoleUser
+}
+
+// commandsFor возвращает меню команд, доступных уровню доступа role, на языке lang
+func (h *Handler) commandsFor(role Role, lang string) []tele.Command {
+	var menu []tele.Command
+	for _, cmd := range h.commands() {
+		if cmd.Role <= role {
+			menu = append(menu, tele.Command{Text: cmd.Name, Description: cmd.description(lang)})
+		}
+	}
+	return menu
+}
+
+// commandScope область видимости меню команд и уровень доступа, команды которого в ней показываются
+type commandScope struct {
+	scope tele.CommandScope
+	role  Role
+}
+
+// publishCommands публикует меню команд в Telegram: общее для всех пользователей
+// и расширенные для чатов сотрудников и администраторов, на каждом из поддерживаемых языков
+func (h *Handler) publishCommands(b *tele.Bot) {
+	scopes := []commandScope{{scope: tele.CommandScope{Type: tele.CommandScopeDefault}, role: RoleUser}}
+	current := h.settings()
+	for id := range current.staff {
+		if _, admin := current.admins[id]; !admin {
+			scopes = append(scopes, commandScope{scope: tele.CommandScope{Type: tele.CommandScopeChat, ChatID: id}, role: RoleStaff})
+		}
+	}
+	for id := range current.admins {
+		scopes = append(scopes, commandScope{scope: tele.CommandScope{Type: tele.CommandScopeChat, ChatID: id}, role: RoleAdmin})
+	}
+
+	for _, s := range scopes {
+		for _, lang := range commandLanguages {
+			if err := b.SetCommands(h.commandsFor(s.role, lang), s.scope, lang); err != nil {
+				h.log.Warn("failed to publish commands", slog.String("scope", s.scope.Type), slog.Int64("chat_id", s.scope.ChatID), slog.String("lang", lang), logger.Err(err))
+			}
+		}
+	}
+	h.log.Info("bot commands published", slog.Int("scopes", len(scopes)))
+}
+
+// deleteCommands удаляет расширенные меню команд из чатов бывших сотрудников и администраторов,
+// после чего в этих чатах показывается общее меню
+func (h *Handler) deleteCommands(b *tele.Bot, chatIDs []int64) {
+	for _, id := range chatIDs {
+		scope := tele.CommandScope{Type: tele.CommandScopeChat, ChatID: id}
+		for _, lang := range commandLanguages {
+			if err := b.DeleteCommands(scope, lang); err != nil {
+				h.log.Warn("failed to delete commands", slog.Int64("chat_id", id), slog.String("lang", lang), logger.Err(err))
+			}
+		}
+	}
+	if len(chatIDs) > 0 {
+		h.log.Info("bot commands deleted", slog.Int("chats", len(chatIDs)))
+	}
+}
+
+// help обработчик для команды /help, перечисляет команды, доступные пользователю
+func (h *Handler) help(c tele.Context) error {
+	lang := langDefault
+	if c.Sender() != nil && strings.HasPrefix(c.Sender().LanguageCode, langEN) {
+		lang = langEN
+	}
+
+	header := "Доступные команды:"
+	if lang == langEN {
+		header = "Available commands:"
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	for _, cmd := range h.commandsFor(h.role(c), lang) {
+		b.WriteString("\n/" + cmd.Text + " — " + cmd.Description)
+	}
+	return c.Send(b.String())
+}
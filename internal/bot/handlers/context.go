@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/reqctx"
+	tele "gopkg.in/telebot.v3"
+)
+
+// requestCtxKey ключ, под которым контекст обновления хранится в tele.Context
+const requestCtxKey = "request_ctx"
+
+// withRequestContext прослойка, создающая для каждого обновления контекст, производный от корневого,
+// со значениями запроса (идентификатор обновления, чат, трассировка)
+func (h *Handler) withRequestContext(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		r := reqctx.Request{UpdateID: c.Update().ID, TraceID: reqctx.NewTraceID()}
+		if c.Chat() != nil {
+			r.ChatID = c.Chat().ID
+		}
+		c.Set(requestCtxKey, reqctx.WithRequest(h.root, r))
+		return next(c)
+	}
+}
+
+// requestContext возвращает контекст обновления, ограниченный таймаутом операции.
+// Контекст отменяется и при остановке приложения
+func (h *Handler) requestContext(c tele.Context) (context.Context, context.CancelFunc) {
+	ctx, ok := c.Get(requestCtxKey).(context.Context)
+	if !ok {
+		ctx = h.root
+	}
+	return context.WithTimeout(ctx, h.timeout)
+}
+
+// setOperation указывает в контексте обновления обрабатываемую операцию, она попадает во все записи лога обработчика
+func (h *Handler) setOperation(c tele.Context, op string) {
+	ctx, ok := c.Get(requestCtxKey).(context.Context)
+	if !ok {
+		ctx = h.root
+	}
+	c.Set(requestCtxKey, reqctx.WithOperation(ctx, op))
+}
+
+// logContext возвращает контекст обновления для логирования в обработчиках, которые не обращаются к сервисному слою
+func (h *Handler) logContext(c tele.Context) context.Context {
+	if ctx, ok := c.Get(requestCtxKey).(context.Context); ok {
+		return ctx
+	}
+	return h.root
+}
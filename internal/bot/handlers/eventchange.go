@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// NotifyEventChanges ставит в очередь уведомления об изменённых и отменённых событиях
+// и рассылает их зарегистрированным пользователям
+func (h *Handler) NotifyEventChanges(ctx context.Context) {
+	if err := h.service.DetectEventChanges(ctx); err != nil {
+		h.log.ErrorContext(ctx, "failed to detect event changes", logger.Err(err))
+	}
+	// Уведомления, оставшиеся от прерванной рассылки, отправляются и без новых изменений
+	h.sendNotifications(ctx)
+}
+
+// eventChangeMessage возвращает текст и клавиатуру уведомления об изменении события
+func eventChangeMessage(change models.EventChange, loc *time.Location) (string, *tele.ReplyMarkup) {
+	if change.Kind == models.EventChangeCancelled {
+		return formatEventChange(change, loc), keyboard.BackToSeeEvents()
+	}
+	return formatEventChange(change, loc), keyboard.OpenEventKeyboard(change.New.EventID)
+}
+
+// formatEventChange форматирует уведомление об изменении события, время показывается в часовом поясе пользователя
+func formatEventChange(c models.EventChange, loc *time.Location) string {
+	startsAt := func(s models.EventSnapshot) string {
+		if s.StartsAt.IsZero() {
+			return "не указано"
+		}
+		return s.StartsAt.In(loc).Format("02.01.2006 15:04")
+	}
+
+	switch c.Kind {
+	case models.EventChangeCancelled:
+		return fmt.Sprintf("Событие «%s» отменено.\n\nОно было назначено на %s.", c.Old.Title, startsAt(c.Old))
+	case models.EventChangeRestored:
+		return fmt.Sprintf("Событие «%s» снова состоится.\n\nНачало: %s.", c.New.Title, startsAt(c.New))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Событие «%s» изменено.\n", c.Old.Title)
+	if c.TitleChanged() {
+		fmt.Fprintf(&b, "\nНовое название: «%s»", c.New.Title)
+	}
+	if c.TimeChanged() {
+		fmt.Fprintf(&b, "\nНачало: %s вместо %s", startsAt(c.New), startsAt(c.Old))
+	}
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// pendingCommentTTL сколько времени после оценки следующее сообщение пользователя считается комментарием к ней
+const pendingCommentTTL = 15 * time.Minute
+
+// SendFeedbackSurveys ставит в очередь просьбу оценить событие для участников завершившихся событий
+// и рассылает её, вызывается планировщиком
+func (h *Handler) SendFeedbackSurveys(ctx context.Context) {
+	if err := h.service.QueueFeedbackSurveys(ctx); err != nil {
+		h.log.ErrorContext(ctx, "failed to queue feedback surveys", logger.Err(err))
+	}
+	h.sendNotifications(ctx)
+}
+
+// feedbackSurveyText возвращает текст просьбы оценить событие
+func feedbackSurveyText(survey models.FeedbackSurvey) string {
+	return fmt.Sprintf("Как прошло событие «%s»? Оцените его от 1 до 5.", survey.EventTitle)
+}
+
+// rateEvent сохраняет оценку события и предлагает оставить комментарий
+func (h *Handler) rateEvent(c tele.Context, data string) error {
+	sep := strings.LastIndex(data, ":")
+	if sep < 0 {
+		h.log.ErrorContext(h.logContext(c), "invalid feedback callback", slog.String("data", data))
+		return nil
+	}
+
+	eventID := data[:sep]
+	rating, err := strconv.Atoi(data[sep+1:])
+	if err != nil {
+		h.log.ErrorContext(h.logContext(c), "invalid rating", slog.String("data", data))
+		return nil
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	chatID := c.Chat().ID
+	if err = h.service.RateEvent(ctx, eventID, chatID, rating); err != nil {
+		h.log.ErrorContext(ctx, "failed to save rating", logger.Err(err))
+		return c.Edit("Не удалось сохранить оценку, попробуйте позже.", keyboard.FeedbackKeyboard(eventID))
+	}
+
+	h.pendingComments.set(chatID, eventID, time.Now())
+
+	return c.Edit(
+		fmt.Sprintf("Спасибо за оценку %d⭐! Если хотите, отправьте комментарий следующим сообщением.", rating),
+		keyboard.SkipCommentKeyboard(),
+	)
+}
+
+// skipComment отменяет ожидание комментария к оценке
+func (h *Handler) skipComment(c tele.Context) error {
+	h.takePendingComment(c.Chat().ID)
+	return c.Edit("Спасибо за отзыв!")
+}
+
+// takePendingComment возвращает событие, к которому пользователь может оставить комментарий, и сбрасывает ожидание.
+// Через pendingCommentTTL после оценки сообщение уже не считается комментарием
+func (h *Handler) takePendingComment(chatID int64) (string, bool) {
+	eventID, ok := h.pendingComments.get(chatID, time.Now())
+	h.pendingComments.delete(chatID)
+	return eventID, ok
+}
+
+// commentEvent сохраняет комментарий к оценке события
+func (h *Handler) commentEvent(c tele.Context, eventID string) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	if err := h.service.CommentEvent(ctx, eventID, c.Chat().ID, c.Text()); err != nil {
+		h.log.ErrorContext(ctx, "failed to save comment", logger.Err(err))
+		return c.Send("Не удалось сохранить комментарий.")
+	}
+	return c.Send("Спасибо за отзыв!")
+}
+
+// feedbackSummary обработчик для команды /feedback [event_id], доступен только администраторам.
+// Без аргументов предлагает выбрать событие из списка
+func (h *Handler) feedbackSummary(c tele.Context) error {
+	if !h.isAdmin(c) {
+		return nil
+	}
+
+	if eventID := c.Message().Payload; eventID != "" {
+		return h.sendFeedbackSummary(c, eventID)
+	}
+	return h.chooseAdminEvent(c, "feedbacksummary", "Выберите событие для просмотра отзывов:")
+}
+
+// sendFeedbackSummary отправляет администратору сводку отзывов о событии
+func (h *Handler) sendFeedbackSummary(c tele.Context, eventID string) error {
+	if !h.isAdmin(c) {
+		return nil
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	summary, err := h.service.GetFeedbackSummary(ctx, eventID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get feedback summary", logger.Err(err))
+		return c.Send("Ошибка при получении отзывов")
+	}
+
+	if summary.Count == 0 {
+		return c.Send("Отзывов о событии пока нет.")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Отзывов: %d\nСредняя оценка: %.2f\n\n", summary.Count, summary.Average)
+	for rating := 5; rating >= 1; rating-- {
+		fmt.Fprintf(&b, "%d⭐ — %d\n", rating, summary.Ratings[rating-1])
+	}
+	if len(summary.Comments) > 0 {
+		b.WriteString("\nПоследние комментарии:\n")
+		for _, comment := range summary.Comments {
+			fmt.Fprintf(&b, "— %s\n", comment)
+		}
+	}
+
+	return c.Send(b.String())
+}
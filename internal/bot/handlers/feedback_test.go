@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingCommentExpires(t *testing.T) {
+	const chatID = 1
+
+	tests := []struct {
+		name      string
+		ratedAgo  time.Duration
+		wantCalls int
+	}{
+		{name: "comment right after rating", ratedAgo: time.Minute, wantCalls: 1},
+		{name: "message long after rating", ratedAgo: pendingCommentTTL + time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeService{}
+			h, b, _ := newTestHandler(t, svc)
+			h.pendingComments.set(chatID, "event-1", time.Now().Add(-tt.ratedAgo))
+
+			b.ProcessUpdate(textUpdate(chatID, "Было отлично"))
+			if got := len(svc.comments); got != tt.wantCalls {
+				t.Errorf("CommentEvent called %d times, want %d", got, tt.wantCalls)
+			}
+
+			// Ожидание комментария сбрасывается в любом случае, следующее сообщение - уже не комментарий
+			b.ProcessUpdate(textUpdate(chatID, "Ещё сообщение"))
+			if got := len(svc.comments); got != tt.wantCalls {
+				t.Errorf("CommentEvent called %d times after second message, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
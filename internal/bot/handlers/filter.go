@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// handleCalendar обрабатывает нажатия в календаре выбора произвольного диапазона дат.
+// Формат данных: <действие>:<значение>:<выбранная начальная дата>
+func (h *Handler) handleCalendar(c tele.Context, data string) error {
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) < 3 {
+		return h.showEvents(c, 0)
+	}
+	action, value, from := parts[0], parts[1], parts[2]
+
+	switch action {
+	case "m":
+		month, err := time.Parse("200601", value)
+		if err != nil {
+			return h.showEvents(c, 0)
+		}
+		return h.sendOrEdit(c, calendarPrompt(from), keyboard.CalendarKeyboard(month, from))
+
+	case "d":
+		day, err := time.Parse("20060102", value)
+		if err != nil {
+			return h.showEvents(c, 0)
+		}
+		if from == "" {
+			return h.sendOrEdit(c, calendarPrompt(value), keyboard.CalendarKeyboard(day, value))
+		}
+		start, err := time.Parse("20060102", from)
+		if err != nil {
+			return h.showEvents(c, 0)
+		}
+		return h.showFilteredEvents(c, models.CustomFilter(start, day), 0)
+	}
+
+	// Нажатие на заголовок или пустую клетку календаря
+	return nil
+}
+
+// calendarPrompt возвращает подсказку для текущего шага выбора диапазона дат
+func calendarPrompt(from string) string {
+	if from == "" {
+		return "Выберите начальную дату:"
+	}
+	return "Выберите конечную дату:"
+}
+
+// setTimezone обработчик для команды /timezone <часовой пояс>, сохраняет часовой пояс для фильтров по дате
+func (h *Handler) setTimezone(c tele.Context) error {
+	timezone := strings.TrimSpace(c.Message().Payload)
+	if timezone == "" {
+		return c.Send("Укажите часовой пояс в формате IANA, например: /timezone Europe/Moscow")
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	if err := h.service.SetUserTimezone(ctx, c.Chat().ID, timezone); err != nil {
+		h.log.ErrorContext(ctx, "failed to set timezone", logger.Err(err))
+		return c.Send("Не удалось сохранить часовой пояс. Проверьте название, например: Europe/Moscow")
+	}
+	return c.Send("Часовой пояс сохранён: " + timezone)
+}
@@ -2,63 +2,156 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
 	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
 	tele "gopkg.in/telebot.v3"
 )
 
 // Service описывает методы для взаимодействия с сервисным слоем
 type Service interface {
 	GetEvents(ctx context.Context) ([]*pb.Event, error)
+	GetAllEvents(ctx context.Context) ([]*pb.Event, error)
+	UserLocation(ctx context.Context, chatID int64) *time.Location
 	GetEvent(ctx context.Context, eventID string) (*pb.Event, error)
-	RegisterUser(ctx context.Context, eventID string, chatID int64, username string) (bool, error)
-	SaveUserInfo(ctx context.Context, chatID int64, username string) error
+	RegisterUser(ctx context.Context, req models.RegistrationRequest) (models.RegistrationOutcome, error)
+	DeliverQueuedRegistrations(ctx context.Context) ([]models.RegistrationDelivery, error)
+	DetectEventChanges(ctx context.Context) error
+	ClaimNotifications(ctx context.Context) ([]models.Notification, error)
+	CompleteNotification(ctx context.Context, n models.Notification) error
+	FailNotification(ctx context.Context, n models.Notification, sendErr error, permanent bool) error
+	ToggleSubscription(ctx context.Context, chatID int64, keywords string) (models.Subscription, error)
+	QueueNewEventAnnouncements(ctx context.Context) error
+	SaveUserInfo(ctx context.Context, profile models.UserProfile) error
+	UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatus) error
+	TouchUser(ctx context.Context, chatID int64) error
+	GetUserStats(ctx context.Context) (map[models.UserStatus]int, error)
+	ExportUserData(ctx context.Context, chatID int64) ([]byte, error)
+	RequestUserDeletion(ctx context.Context, chatID int64) error
+	CancelUserDeletion(ctx context.Context, chatID int64) error
+	DeleteUserData(ctx context.Context, chatID int64) (bool, error)
+	ExportParticipants(ctx context.Context, eventID string, columns []string) ([]byte, error)
+	IssueTicket(ctx context.Context, eventID string, chatID int64) (string, error)
+	CheckIn(ctx context.Context, code string, staffChatID int64) (models.CheckIn, error)
+	QueueFeedbackSurveys(ctx context.Context) error
+	RateEvent(ctx context.Context, eventID string, chatID int64, rating int) error
+	CommentEvent(ctx context.Context, eventID string, chatID int64, comment string) error
+	GetFeedbackSummary(ctx context.Context, eventID string) (models.FeedbackSummary, error)
+	SearchEvents(ctx context.Context, query string) (models.SearchResult, error)
+	GetFilteredEvents(ctx context.Context, filter string, loc *time.Location) ([]*pb.Event, string, error)
+	GetEventExtras(ctx context.Context, event *pb.Event) (models.EventExtras, error)
+	UpdateEventExtras(ctx context.Context, eventID string, fields map[string]string) (models.EventExtras, error)
+	SetUserTimezone(ctx context.Context, chatID int64, timezone string) error
 }
 
 // Handler описывает слой обработчиков
 type Handler struct {
 	log     *slog.Logger
 	service Service
+	bot     *tele.Bot
+	// current перезагружаемые настройки, см. UpdateSettings
+	current atomic.Pointer[settings]
+
+	// root корневой контекст приложения, задаётся в RegisterHandlers
+	root context.Context
+	// timeout ограничение времени одной операции обработчика
+	timeout time.Duration
+
+	mu sync.Mutex
+	// checkInMode чаты сотрудников, включивших режим отметки посещения
+	checkInMode map[int64]struct{}
+
+	// pendingComments события, к оценке которых пользователь может оставить комментарий
+	pendingComments *chatState[string]
+	// searches последний поисковый запрос в чате, нужен для листания результатов
+	searches *chatState[string]
+
+	callbacks *callbackGuard
+	// broadcaster ограничивает частоту рассылок уведомлений и анонсов
+	broadcaster broadcaster
+}
+
+// Options параметры обработчиков
+type Options struct {
+	// Timeout ограничение времени одной операции обработчика
+	Timeout time.Duration
+	// Settings начальные значения перезагружаемых настроек
+	Settings Settings
 }
 
 // NewHandler конструктор для Handler
-func NewHandler(log *slog.Logger, service Service, _ *tele.Bot) *Handler {
-	return &Handler{
-		log:     log,
-		service: service,
+func NewHandler(log *slog.Logger, service Service, b *tele.Bot, opts Options) *Handler {
+	h := &Handler{
+		log:             log,
+		service:         service,
+		bot:             b,
+		root:            context.Background(),
+		timeout:         opts.Timeout,
+		checkInMode:     make(map[int64]struct{}),
+		pendingComments: newChatState[string](pendingCommentTTL),
+		searches:        newChatState[string](searchTTL),
+		callbacks:       newCallbackGuard(),
 	}
+	h.current.Store(newSettings(opts.Settings))
+	return h
 }
 
-// RegisterHandlers регистрирует обработчики для клавиатур и комманд
-func (h *Handler) RegisterHandlers(b *tele.Bot) {
-	b.Handle("/start", h.startMessage)
+// idSet преобразует список идентификаторов в множество
+func idSet(ids []int64) map[int64]struct{} {
+	set := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+// RegisterHandlers регистрирует обработчики для клавиатур и комманд.
+// Контексты обработчиков создаются от ctx, поэтому его отмена прерывает выполняющиеся операции
+func (h *Handler) RegisterHandlers(ctx context.Context, b *tele.Bot) {
+	h.root = ctx
+
+	b.Use(h.withRequestContext)
+	b.Use(h.trackUserStatus)
+
+	h.registerCommands(b)
+	b.Handle(tele.OnMyChatMember, h.handleMyChatMember)
 	b.Handle(tele.OnText, h.handleText)
 	b.Handle(tele.OnCallback, h.handleCallback)
+
+	h.publishCommands(b)
 }
 
 // startMessage обработчик для команды /start
 func (h *Handler) startMessage(c tele.Context) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := h.requestContext(c)
 	defer cancel()
 
-	chatID := c.Chat().ID
-	username := c.Sender().Username
+	sender := c.Sender()
+	profile := models.UserProfile{
+		ChatID:       c.Chat().ID,
+		Username:     sender.Username,
+		FirstName:    sender.FirstName,
+		LastName:     sender.LastName,
+		LanguageCode: sender.LanguageCode,
+	}
 
-	h.log.Info("saving user info", slog.Int64("chat_id", chatID), slog.String("username", username))
-	if err := h.service.SaveUserInfo(ctx, chatID, username); err != nil {
-		h.log.Error("failed to save user", slog.String("error", err.Error()))
+	h.log.InfoContext(ctx, "saving user info", slog.Int64("chat_id", profile.ChatID), slog.String("username", profile.Username))
+	if err := h.service.SaveUserInfo(ctx, profile); err != nil {
+		h.log.ErrorContext(ctx, "failed to save user", logger.Err(err))
 	}
 
-	return c.Send(
-		"Привет! 👋\nЯ бот для отслеживания и регистрации на события.",
-		keyboard.MainKeyboard(),
-	)
+	return c.Send(h.settings().welcomeMessage, keyboard.MainKeyboard())
 }
 
 // handleText обработчик для текстовых сообщений
@@ -66,30 +159,76 @@ func (h *Handler) handleText(c tele.Context) error {
 	if c.Text() == "Посмотреть предстоящие события" {
 		return h.showEvents(c, 0)
 	}
-	return nil
+	// Режим отметки мог остаться у пользователя, которого исключили из сотрудников
+	if h.inCheckInMode(c.Chat().ID) && h.role(c) >= RoleStaff {
+		return h.checkInTicket(c, c.Text())
+	}
+	if eventID, ok := h.takePendingComment(c.Chat().ID); ok {
+		return h.commentEvent(c, eventID)
+	}
+	if !h.settings().searchEnabled || strings.HasPrefix(c.Text(), "/") {
+		return nil
+	}
+	return h.searchEvents(c, c.Text(), 0)
 }
 
-// showEvents показывает список событий
+// showEvents показывает список всех событий
 func (h *Handler) showEvents(c tele.Context, pageNum int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	return h.showFilteredEvents(c, models.FilterAll, pageNum)
+}
+
+// showFilteredEvents показывает список событий с фильтром по дате, активный фильтр отображается в заголовке
+func (h *Handler) showFilteredEvents(c tele.Context, filter string, pageNum int) error {
+	ctx, cancel := h.requestContext(c)
 	defer cancel()
 
-	events, err := h.service.GetEvents(ctx)
+	// Часовой пояс нужен и для фильтра, и для отметок времени на кнопках, поэтому загружается один раз
+	loc := h.service.UserLocation(ctx, c.Chat().ID)
+	events, label, err := h.service.GetFilteredEvents(ctx, filter, loc)
+	if errors.Is(err, models.ErrInvalidFilter) {
+		filter = models.FilterAll
+		events, label, err = h.service.GetFilteredEvents(ctx, filter, loc)
+	}
 	if err != nil {
 		return c.Send("Ошибка при получении событий")
 	}
 
-	h.log.Info("events from service", slog.Int("count", len(events)))
+	h.log.InfoContext(ctx, "events from service", slog.Int("count", len(events)), slog.String("filter", filter))
 
-	if len(events) == 0 {
+	if len(events) == 0 && filter == models.FilterAll {
 		return c.Send("Событий не найдено")
 	}
 
-	pageSize := 5
+	header := "Выберите событие:"
+	if label != "" {
+		header = "*Фильтр:* " + label + "\n\n" + header
+	}
+	if len(events) == 0 {
+		header = "*Фильтр:* " + label + "\n\nСобытий за этот период не найдено."
+	}
+
+	pageSize := h.settings().pageSize
+	buttons, pageNum := pageButtons(events, pageNum, pageSize, loc)
+	return h.sendOrEdit(c, header, keyboard.FilteredEventsKeyboard(buttons, filter, pageNum, pageSize, len(events)))
+}
+
+// sendEventsPage отправляет (или редактирует при нажатии на кнопку) страницу списка событий
+func (h *Handler) sendEventsPage(c tele.Context, header string, events []*pb.Event, pageAction string, pageNum int) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	pageSize := h.settings().pageSize
+	buttons, pageNum := pageButtons(events, pageNum, pageSize, h.service.UserLocation(ctx, c.Chat().ID))
+	return h.sendOrEdit(c, header, keyboard.EventsKeyboard(buttons, pageAction, pageNum, pageSize, len(events)))
+}
+
+// pageButtons возвращает кнопки событий для страницы pageNum размером pageSize и номер фактически показанной страницы,
+// к названию события добавляется отметка о времени начала в часовом поясе пользователя
+func pageButtons(events []*pb.Event, pageNum, pageSize int, loc *time.Location) ([]keyboard.EventButton, int) {
 	totalEvents := len(events)
 	start := pageNum * pageSize
 
-	if start >= totalEvents {
+	if start >= totalEvents || start < 0 {
 		start = 0
 		pageNum = 0
 	}
@@ -99,20 +238,64 @@ func (h *Handler) showEvents(c tele.Context, pageNum int) error {
 		end = totalEvents
 	}
 
+	now := time.Now()
 	var buttons []keyboard.EventButton
 	for i := start; i < end; i++ {
 		e := events[i]
+		title := e.Title
+		if marker := startMarker(e, now, loc); marker != "" {
+			title = marker + " · " + title
+		}
 		buttons = append(buttons, keyboard.EventButton{
 			EventID: e.Id,
-			Title:   e.Title,
+			Title:   title,
 		})
 	}
+	return buttons, pageNum
+}
+
+// weekdayNames сокращённые названия дней недели, индекс соответствует time.Weekday
+var weekdayNames = [...]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
 
-	markup := keyboard.EventsKeyboard(buttons, pageNum, pageSize, totalEvents)
+// startMarker возвращает отметку о времени начала события относительно текущего дня:
+// "Идёт", "Сегодня 19:00", "Завтра 19:00", день недели для ближайшей недели, иначе дату
+func startMarker(e *pb.Event, now time.Time, loc *time.Location) string {
+	if e.GetStartsAt() == nil {
+		return ""
+	}
+
+	startsAt := e.GetStartsAt().AsTime().In(loc)
+	now = now.In(loc)
+	if startsAt.Before(now) {
+		return "Идёт"
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	day := time.Date(startsAt.Year(), startsAt.Month(), startsAt.Day(), 0, 0, 0, 0, loc)
+	clock := startsAt.Format("15:04")
+
+	switch days := int(math.Round(day.Sub(today).Hours() / 24)); {
+	case days == 0:
+		return "Сегодня " + clock
+	case days == 1:
+		return "Завтра " + clock
+	case days < 7:
+		return weekdayNames[startsAt.Weekday()] + " " + clock
+	default:
+		return startsAt.Format("02.01")
+	}
+}
 
-	if c.Callback() != nil {
+// sendOrEdit редактирует сообщение, если обновление пришло от кнопки, иначе отправляет новое
+// Сообщение с фото нельзя отредактировать в текстовое, поэтому оно удаляется и отправляется новое
+func (h *Handler) sendOrEdit(c tele.Context, text string, markup *tele.ReplyMarkup) error {
+	if cb := c.Callback(); cb != nil && cb.Message != nil && cb.Message.Photo != nil {
+		if err := c.Delete(); err != nil {
+			h.log.WarnContext(h.logContext(c), "failed to delete photo card", logger.Err(err))
+		}
+	} else if cb != nil {
 		return c.Edit(
-			"Выберите событие:",
+			text,
 			&tele.SendOptions{
 				ParseMode:   tele.ModeMarkdown,
 				ReplyMarkup: markup,
@@ -121,7 +304,7 @@ func (h *Handler) showEvents(c tele.Context, pageNum int) error {
 	}
 
 	return c.Send(
-		"Выберите событие:",
+		text,
 		&tele.SendOptions{
 			ParseMode:   tele.ModeMarkdown,
 			ReplyMarkup: markup,
@@ -130,34 +313,40 @@ func (h *Handler) showEvents(c tele.Context, pageNum int) error {
 }
 
 // formatEventInfo форматирует строку с деталями информации
-func formatEventInfo(e *pb.Event) string {
+func formatEventInfo(e *pb.Event, extras models.EventExtras) string {
 	t := e.StartsAt.AsTime().Format("02.01.2006 15:04")
-	return fmt.Sprintf("*%s*\n\n%s\n\n*Начало:* %s",
+	text := fmt.Sprintf("*%s*\n\n%s\n\n*Начало:* %s",
 		e.GetTitle(), e.GetDescription(), t)
+	if extras.Address != "" {
+		text += "\n*Место:* " + extras.Address
+	}
+	return text
 }
 
-// showEventDetails показывает детали события
-func (h *Handler) showEventDetails(c tele.Context, eventID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// showEventDetails показывает детали события. back - callback кнопки возврата, пустой - к общему списку событий
+func (h *Handler) showEventDetails(c tele.Context, eventID, back string) error {
+	ctx, cancel := h.requestContext(c)
 	defer cancel()
 
-	h.log.Info("showing event details", slog.String("event_id", eventID))
+	h.log.InfoContext(ctx, "showing event details", slog.String("event_id", eventID))
 
 	event, err := h.service.GetEvent(ctx, eventID)
 	if err != nil || event == nil {
 		return h.showEvents(c, 0)
 	}
 
-	text := formatEventInfo(event)
-	markup := keyboard.EventDetailKeyboard(eventID)
+	extras, err := h.service.GetEventExtras(ctx, event)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get event extras", logger.Err(err))
+	}
 
-	return c.Edit(
-		text,
-		&tele.SendOptions{
-			ParseMode:   tele.ModeMarkdown,
-			ReplyMarkup: markup,
-		},
-	)
+	text := formatEventInfo(event, extras)
+	markup := keyboard.EventCardKeyboard(eventID, extras.HasLocation() || extras.Address != "", extras.URL, back)
+
+	if extras.Photo != "" {
+		return h.sendPhotoCard(c, extras.Photo, text, markup)
+	}
+	return h.sendOrEdit(c, text, markup)
 }
 
 // backToEvents возвращает назад к просмотру событий
@@ -167,82 +356,154 @@ func (h *Handler) backToEvents(c tele.Context) error {
 
 // register регистрирует пользователя на событие
 func (h *Handler) register(c tele.Context, eventID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := h.requestContext(c)
 	defer cancel()
 
-	user := c.Sender()
+	req := models.RegistrationRequest{
+		EventID:  eventID,
+		ChatID:   c.Chat().ID,
+		Username: c.Sender().Username,
+	}
 
-	success, err := h.service.RegisterUser(ctx, eventID, c.Chat().ID, user.Username)
-	if err != nil {
-		return c.Edit(
-			"Произошла ошибка.",
-			&tele.SendOptions{
-				ParseMode:   tele.ModeMarkdown,
-				ReplyMarkup: keyboard.EventDetailKeyboard(eventID),
-			},
-		)
+	// Сразу показываем, что запрос принят, чтобы пользователь не нажимал кнопку повторно
+	req.IdempotencyKey = "register:" + strconv.FormatInt(req.ChatID, 10) + ":" + eventID
+	if msg := c.Message(); msg != nil {
+		// Повторное нажатие на то же сообщение приходит с новым идентификатором callback-запроса уже после
+		// завершения первого, поэтому отсекается по сообщению, а не полагается на идемпотентность микросервиса событий
+		if !h.callbacks.claimAction("register", req.ChatID, msg.ID, time.Now()) {
+			h.log.InfoContext(ctx, "repeated registration ignored", slog.String("event_id", eventID))
+			return nil
+		}
+		req.IdempotencyKey += ":" + strconv.Itoa(msg.ID)
+		req.MessageID = msg.ID
+		if _, err := h.bot.EditReplyMarkup(msg, keyboard.ProcessingKeyboard()); err != nil {
+			h.log.WarnContext(ctx, "failed to show processing state", logger.Err(err))
+		}
 	}
 
-	if success {
-		return c.Edit(
-			"Вы успешно зарегистрированы на это событие!",
-			&tele.SendOptions{
-				ParseMode:   tele.ModeMarkdown,
-				ReplyMarkup: keyboard.BackToSeeEvents(),
-			},
-		)
+	outcome, err := h.service.RegisterUser(ctx, req)
+	if err != nil {
+		if req.MessageID != 0 {
+			h.callbacks.releaseAction("register", req.ChatID, req.MessageID)
+		}
+		return h.sendOrEdit(c, "Произошла ошибка.", keyboard.EventDetailKeyboard(eventID))
 	}
 
-	return c.Edit(
-		"Не удалось зарегистрироваться. Возможно, вы уже зарегистрированы на это событие.",
-		&tele.SendOptions{
-			ParseMode:   tele.ModeMarkdown,
-			ReplyMarkup: keyboard.BackToSeeEvents(),
-		},
-	)
+	if err = h.sendOrEdit(c, registrationText(outcome), keyboard.BackToSeeEvents()); err != nil {
+		return err
+	}
+	if outcome == models.RegistrationConfirmed {
+		return h.sendTicket(ctx, req.ChatID, eventID)
+	}
+	return nil
 }
 
 // handleCallback обработчик callback'ов
 func (h *Handler) handleCallback(c tele.Context) error {
 	callback := c.Callback()
 
-	h.log.Info("callback received", slog.String("data", callback.Data), slog.Int64("chat_id", c.Chat().ID))
+	action, _, _ := strings.Cut(callback.Data, ":")
+	h.setOperation(c, "callback."+action)
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+	h.log.InfoContext(ctx, "callback received", slog.String("data", callback.Data))
+
+	if h.callbacks.seenBefore(callback.ID, time.Now()) {
+		h.log.InfoContext(ctx, "duplicate callback ignored", slog.String("callback_id", callback.ID))
+		return nil
+	}
+
+	if callback.Message != nil {
+		unlock, ok := h.callbacks.lock(c.Chat().ID, callback.Message.ID)
+		if !ok {
+			return c.Respond(&tele.CallbackResponse{Text: "Запрос уже обрабатывается…"})
+		}
+		defer unlock()
+	}
 
 	if err := c.Respond(); err != nil {
-		h.log.Error("failed to respond to callback", slog.String("error", err.Error()))
+		h.log.ErrorContext(ctx, "failed to respond to callback", logger.Err(err))
 	}
 
 	parts := strings.SplitN(callback.Data, ":", 2)
 	if len(parts) < 2 {
-		h.log.Error("invalid callback format", slog.String("data", callback.Data))
+		h.log.ErrorContext(ctx, "invalid callback format", slog.String("data", callback.Data))
 		return h.showEvents(c, 0)
 	}
 
-	action := parts[0]
 	data := parts[1]
 
-	h.log.Info("parsed callback", slog.String("action", action), slog.String("data", data))
+	h.log.InfoContext(ctx, "parsed callback", slog.String("action", action), slog.String("data", data))
 
 	switch action {
+	case "noop":
+		return nil
+
 	case "event":
-		return h.showEventDetails(c, data)
+		eventID, back, _ := strings.Cut(data, ":")
+		return h.showEventDetails(c, eventID, back)
 
 	case "page":
 		page, err := strconv.Atoi(data)
 		if err != nil {
-			h.log.Error("invalid page number", slog.String("data", data))
+			h.log.ErrorContext(ctx, "invalid page number", slog.String("data", data))
 			return h.showEvents(c, 0)
 		}
 		return h.showEvents(c, page)
 
+	case "filter":
+		return h.showFilteredEvents(c, data, 0)
+
+	case "fpage":
+		sep := strings.LastIndex(data, ":")
+		if sep < 0 {
+			return h.showEvents(c, 0)
+		}
+		page, err := strconv.Atoi(data[sep+1:])
+		if err != nil {
+			h.log.ErrorContext(ctx, "invalid page number", slog.String("data", data))
+			return h.showEvents(c, 0)
+		}
+		return h.showFilteredEvents(c, data[:sep], page)
+
+	case "cal":
+		return h.handleCalendar(c, data)
+
+	case "searchpage":
+		page, err := strconv.Atoi(data)
+		if err != nil {
+			h.log.ErrorContext(ctx, "invalid page number", slog.String("data", data))
+			return h.showEvents(c, 0)
+		}
+		return h.searchPage(c, page)
+
 	case "back":
 		return h.backToEvents(c)
 
+	case "map":
+		return h.sendEventLocation(c, data)
+
 	case "register":
 		return h.register(c, data)
 
+	case "deleteme":
+		return h.confirmDeleteMe(c, data)
+
+	case "participants":
+		return h.sendParticipants(c, data, nil)
+
+	case "feedback":
+		return h.rateEvent(c, data)
+
+	case "feedbackskip":
+		return h.skipComment(c)
+
+	case "feedbacksummary":
+		return h.sendFeedbackSummary(c, data)
+
 	default:
-		h.log.Warn("unknown callback action", slog.String("action", action))
+		h.log.WarnContext(ctx, "unknown callback action", slog.String("action", action))
 		return h.showEvents(c, 0)
 	}
 }
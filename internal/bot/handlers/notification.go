@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// sendNotifications рассылает уведомления из очереди с ограничением частоты рассылок, пока очередь не опустеет
+// или не истечёт время задачи. Каждое уведомление отмечается отправленным сразу после доставки,
+// а неотправленные вернутся в рассылку после окончания аренды
+func (h *Handler) sendNotifications(ctx context.Context) {
+	for ctx.Err() == nil {
+		notifications, err := h.service.ClaimNotifications(ctx)
+		if err != nil {
+			h.log.ErrorContext(ctx, "failed to claim notifications", logger.Err(err))
+			return
+		}
+		if len(notifications) == 0 {
+			return
+		}
+
+		sent := 0
+		for _, n := range notifications {
+			if ctx.Err() != nil {
+				return
+			}
+			if h.sendNotification(ctx, n) {
+				sent++
+			}
+		}
+		h.log.InfoContext(ctx, "notifications sent", slog.Int("sent", sent), slog.Int("claimed", len(notifications)))
+	}
+}
+
+// sendNotification отправляет одно уведомление и сохраняет результат отправки
+func (h *Handler) sendNotification(ctx context.Context, n models.Notification) bool {
+	text, markup, err := h.notificationMessage(ctx, n)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to build notification", slog.Int64("id", n.ID), logger.Err(err))
+		h.failNotification(ctx, n, err, true)
+		return false
+	}
+
+	if err = h.broadcast(ctx, n.ChatID, text, markup); err == nil {
+		if err = h.service.CompleteNotification(ctx, n); err != nil {
+			h.log.ErrorContext(ctx, "failed to complete notification", slog.Int64("id", n.ID), logger.Err(err))
+		}
+		return true
+	}
+	if ctx.Err() != nil {
+		// Рассылка прервана, уведомление вернётся в очередь после окончания аренды
+		return false
+	}
+
+	h.log.ErrorContext(ctx, "failed to send notification", slog.Int64("chat_id", n.ChatID), slog.String("kind", string(n.Kind)), logger.Err(err))
+	_, permanent := statusFromSendError(err)
+	h.failNotification(ctx, n, err, permanent)
+	return false
+}
+
+// failNotification сохраняет ошибку отправки уведомления
+func (h *Handler) failNotification(ctx context.Context, n models.Notification, sendErr error, permanent bool) {
+	if err := h.service.FailNotification(ctx, n, sendErr, permanent); err != nil {
+		h.log.ErrorContext(ctx, "failed to save notification failure", slog.Int64("id", n.ID), logger.Err(err))
+	}
+}
+
+// notificationMessage возвращает текст и клавиатуру уведомления в часовом поясе получателя
+func (h *Handler) notificationMessage(ctx context.Context, n models.Notification) (string, *tele.ReplyMarkup, error) {
+	loc := h.service.UserLocation(ctx, n.ChatID)
+	switch n.Kind {
+	case models.NotificationEventChange:
+		text, markup := eventChangeMessage(*n.Change, loc)
+		return text, markup, nil
+	case models.NotificationFeedbackSurvey:
+		return feedbackSurveyText(*n.Survey), keyboard.FeedbackKeyboard(n.Survey.EventID), nil
+	case models.NotificationEventAnnouncement:
+		return formatAnnouncement(*n.Announcement, loc), keyboard.OpenEventKeyboard(n.Announcement.EventID), nil
+	}
+	return "", nil, fmt.Errorf("unsupported notification kind %q", n.Kind)
+}
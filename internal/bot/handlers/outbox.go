@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// registrationText возвращает сообщение пользователю о результате регистрации
+func registrationText(outcome models.RegistrationOutcome) string {
+	switch outcome {
+	case models.RegistrationConfirmed:
+		return "Вы успешно зарегистрированы на это событие!"
+	case models.RegistrationAlreadyExists:
+		return "Вы уже зарегистрированы на это событие."
+	case models.RegistrationQueued:
+		return "Заявка принята, подтвердим регистрацию, как только сервис событий станет доступен."
+	case models.RegistrationAlreadyQueued:
+		return "Заявка уже принята, результат регистрации придёт отдельным сообщением."
+	case models.RegistrationFailed:
+		return "Не удалось зарегистрироваться: сервис событий долго недоступен. Попробуйте зарегистрироваться ещё раз позже."
+	default:
+		return "Не удалось зарегистрироваться. Возможно, вы уже зарегистрированы на это событие."
+	}
+}
+
+// DeliverQueuedRegistrations отправляет отложенные заявки на регистрацию и сообщает пользователям результат.
+// Сообщение с карточкой события, в котором была принята заявка, по возможности заменяется результатом
+func (h *Handler) DeliverQueuedRegistrations(ctx context.Context) {
+	deliveries, err := h.service.DeliverQueuedRegistrations(ctx)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to deliver queued registrations", logger.Err(err))
+		return
+	}
+
+	for _, d := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		req := d.Request
+		text := registrationText(d.Outcome)
+		markup := keyboard.BackToSeeEvents()
+		if d.Outcome == models.RegistrationFailed {
+			markup = keyboard.EventDetailKeyboard(req.EventID)
+		}
+
+		edited := false
+		if req.MessageID != 0 {
+			msg := tele.StoredMessage{MessageID: strconv.Itoa(req.MessageID), ChatID: req.ChatID}
+			// Карточку с фотографией нельзя превратить в текст, тогда результат отправляется новым сообщением
+			if _, err = h.bot.Edit(msg, text, markup); err == nil {
+				edited = true
+			}
+		}
+		if !edited {
+			if err = h.notify(ctx, req.ChatID, text, markup); err != nil {
+				h.log.ErrorContext(ctx, "failed to send registration result", slog.Int64("chat_id", req.ChatID), logger.Err(err))
+				continue
+			}
+		}
+
+		if d.Outcome == models.RegistrationConfirmed {
+			if err = h.sendTicket(ctx, req.ChatID, req.EventID); err != nil {
+				h.log.ErrorContext(ctx, "failed to send ticket", slog.Int64("chat_id", req.ChatID), logger.Err(err))
+			}
+		}
+	}
+}
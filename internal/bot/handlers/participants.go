@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// participants обработчик для команды /participants [event_id] [колонки через запятую], доступен только администраторам.
+// Участники выгружаются только в CSV, выгрузка в XLSX не поддерживается.
+// Без аргументов предлагает выбрать событие из списка
+func (h *Handler) participants(c tele.Context) error {
+	if !h.isAdmin(c) {
+		return nil
+	}
+
+	args := c.Args()
+	if len(args) == 0 {
+		return h.chooseAdminEvent(c, "participants", "Выберите событие для выгрузки участников:")
+	}
+
+	var columns []string
+	if len(args) > 1 {
+		for _, column := range strings.Split(args[1], ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	return h.sendParticipants(c, args[0], columns)
+}
+
+// chooseAdminEvent показывает администратору список событий для выбора события, к которому применяется действие
+func (h *Handler) chooseAdminEvent(c tele.Context, action, prompt string) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	events, err := h.service.GetAllEvents(ctx)
+	if err != nil {
+		return c.Send("Ошибка при получении событий")
+	}
+
+	if len(events) == 0 {
+		return c.Send("Событий не найдено")
+	}
+
+	buttons := make([]keyboard.EventButton, 0, len(events))
+	for _, e := range events {
+		buttons = append(buttons, keyboard.EventButton{EventID: e.Id, Title: e.Title})
+	}
+
+	return c.Send(prompt, keyboard.AdminEventsKeyboard(action, buttons))
+}
+
+// sendParticipants отправляет администратору CSV-файл с участниками события
+func (h *Handler) sendParticipants(c tele.Context, eventID string, columns []string) error {
+	if !h.isAdmin(c) {
+		return nil
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	h.log.InfoContext(ctx, "exporting participants", slog.String("event_id", eventID), slog.Int64("chat_id", c.Chat().ID))
+
+	data, err := h.service.ExportParticipants(ctx, eventID, columns)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to export participants", logger.Err(err))
+		if errors.Is(err, models.ErrUnknownColumn) {
+			return c.Send("Неизвестная колонка. Доступные колонки: " + strings.Join(models.ParticipantColumns, ", "))
+		}
+		return c.Send("Не удалось выгрузить участников, попробуйте позже.")
+	}
+
+	return c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: "participants_" + eventID + ".csv",
+		MIME:     "text/csv",
+		Caption:  "Участники события",
+	})
+}
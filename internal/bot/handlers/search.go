@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	tele "gopkg.in/telebot.v3"
+)
+
+// searchTTL сколько времени после поиска можно листать его результаты
+const searchTTL = 30 * time.Minute
+
+// searchEvents ищет события по тексту сообщения и показывает результаты постранично
+func (h *Handler) searchEvents(c tele.Context, query string, pageNum int) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	h.searches.set(c.Chat().ID, query, time.Now())
+
+	result, err := h.service.SearchEvents(ctx, query)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to search events", logger.Err(err))
+		return c.Send("Ошибка при поиске событий")
+	}
+
+	if len(result.Events) > 0 {
+		return h.sendEventsPage(c, fmt.Sprintf("Найдено событий: %d", len(result.Events)), result.Events, "searchpage", pageNum)
+	}
+
+	if len(result.Suggestions) == 0 {
+		return c.Send("По запросу ничего не найдено. Попробуйте другие слова или посмотрите все предстоящие события.", keyboard.BackToSeeEvents())
+	}
+
+	buttons := make([]keyboard.EventButton, 0, len(result.Suggestions))
+	for _, e := range result.Suggestions {
+		buttons = append(buttons, keyboard.EventButton{EventID: e.Id, Title: e.Title})
+	}
+	return c.Send(
+		"По запросу ничего не найдено. Возможно, вы искали:",
+		keyboard.EventsKeyboard(buttons, "searchpage", 0, len(buttons), len(buttons)),
+	)
+}
+
+// searchPage показывает другую страницу результатов последнего поиска в чате.
+// Если поиск был слишком давно, показывается список всех событий
+func (h *Handler) searchPage(c tele.Context, pageNum int) error {
+	query, ok := h.searches.get(c.Chat().ID, time.Now())
+	if !ok {
+		return h.showEvents(c, 0)
+	}
+	return h.searchEvents(c, query, pageNum)
+}
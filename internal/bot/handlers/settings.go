@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// settings настройки обработчиков, которые можно изменить без перезапуска бота
+type settings struct {
+	admins map[int64]struct{}
+	staff  map[int64]struct{}
+	// pageSize количество событий на одной странице списка
+	pageSize int
+	// searchEnabled включён ли поиск событий по тексту сообщения
+	searchEnabled bool
+	// welcomeMessage приветствие в ответ на /start
+	welcomeMessage string
+}
+
+// Settings перезагружаемые настройки обработчиков
+type Settings struct {
+	// AdminIDs Telegram ID администраторов бота
+	AdminIDs []int64
+	// StaffIDs Telegram ID сотрудников, отмечающих посещение событий
+	StaffIDs []int64
+	// PageSize количество событий на одной странице списка
+	PageSize int
+	// SearchEnabled включён ли поиск событий по тексту сообщения
+	SearchEnabled bool
+	// WelcomeMessage приветствие в ответ на /start
+	WelcomeMessage string
+}
+
+// newSettings конструктор для settings
+func newSettings(s Settings) *settings {
+	return &settings{
+		admins:         idSet(s.AdminIDs),
+		staff:          idSet(s.StaffIDs),
+		pageSize:       s.PageSize,
+		searchEnabled:  s.SearchEnabled,
+		welcomeMessage: s.WelcomeMessage,
+	}
+}
+
+// settings возвращает текущие настройки обработчиков
+func (h *Handler) settings() *settings {
+	return h.current.Load()
+}
+
+// UpdateSettings атомарно заменяет настройки обработчиков. Если изменились списки администраторов
+// или сотрудников, меню команд в Telegram публикуется заново, а у исключённых из списков удаляется
+// вместе с режимом отметки посещения
+func (h *Handler) UpdateSettings(s Settings) {
+	next := newSettings(s)
+	prev := h.current.Swap(next)
+	h.log.Info("handler settings updated", slog.Int("page_size", s.PageSize), slog.Bool("search_enabled", s.SearchEnabled))
+
+	if !maps.Equal(prev.admins, next.admins) || !maps.Equal(prev.staff, next.staff) {
+		removed := removedIDs(prev, next)
+		h.mu.Lock()
+		for _, id := range removed {
+			delete(h.checkInMode, id)
+		}
+		h.mu.Unlock()
+
+		h.deleteCommands(h.bot, removed)
+		h.publishCommands(h.bot)
+	}
+}
+
+// removedIDs возвращает администраторов и сотрудников из prev, которых нет ни в одном из списков next
+func removedIDs(prev, next *settings) []int64 {
+	var removed []int64
+	for _, set := range []map[int64]struct{}{prev.admins, prev.staff} {
+		for id := range set {
+			_, admin := next.admins[id]
+			_, staff := next.staff[id]
+			if !admin && !staff && !slices.Contains(removed, id) {
+				removed = append(removed, id)
+			}
+		}
+	}
+	return removed
+}
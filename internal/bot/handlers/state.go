@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// chatEntry значение состояния чата и время его записи
+type chatEntry[V any] struct {
+	value V
+	at    time.Time
+}
+
+// chatState временное состояние диалога с пользователем, например последний поисковый запрос.
+// Записи старше ttl не возвращаются и периодически удаляются, чтобы состояние не копилось для каждого чата
+type chatState[V any] struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[int64]chatEntry[V]
+	lastSweep time.Time
+}
+
+// newChatState конструктор для chatState
+func newChatState[V any](ttl time.Duration) *chatState[V] {
+	return &chatState[V]{
+		ttl:       ttl,
+		entries:   make(map[int64]chatEntry[V]),
+		lastSweep: time.Now(),
+	}
+}
+
+// set запоминает значение для чата chatID
+func (s *chatState[V]) set(chatID int64, value V, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	s.entries[chatID] = chatEntry[V]{value: value, at: now}
+}
+
+// get возвращает значение для чата chatID, если оно записано не раньше ttl назад
+func (s *chatState[V]) get(chatID int64, now time.Time) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[chatID]
+	if !ok || now.Sub(entry.at) > s.ttl {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// delete удаляет значение для чата chatID
+func (s *chatState[V]) delete(chatID int64) {
+	s.mu.Lock()
+	delete(s.entries, chatID)
+	s.mu.Unlock()
+}
+
+// sweep удаляет устаревшие записи не чаще раза в ttl, вызывается под s.mu
+func (s *chatState[V]) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) <= s.ttl {
+		return
+	}
+	for chatID, entry := range s.entries {
+		if now.Sub(entry.at) > s.ttl {
+			delete(s.entries, chatID)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChatState(t *testing.T) {
+	const ttl = time.Minute
+	start := time.Now()
+
+	tests := []struct {
+		name   string
+		after  time.Duration
+		delete bool
+		wantOK bool
+	}{
+		{name: "fresh", after: time.Second, wantOK: true},
+		{name: "at ttl", after: ttl, wantOK: true},
+		{name: "expired", after: ttl + time.Second},
+		{name: "deleted", after: time.Second, delete: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newChatState[string](ttl)
+			s.set(1, "концерт", start)
+			if tt.delete {
+				s.delete(1)
+			}
+
+			got, ok := s.get(1, start.Add(tt.after))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != "концерт" {
+				t.Errorf("got %q, want %q", got, "концерт")
+			}
+		})
+	}
+}
+
+func TestChatStateSweepsExpiredEntries(t *testing.T) {
+	const ttl = time.Minute
+	start := time.Now()
+
+	s := newChatState[string](ttl)
+	for chatID := range int64(100) {
+		s.set(chatID, "запрос", start)
+	}
+	s.set(1000, "новый запрос", start.Add(2*ttl))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(s.entries))
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// trackUserStatus прослойка, отслеживающая ошибки отправки сообщений пользователю:
+// если пользователь заблокировал бота или удалил аккаунт, его статус обновляется в базе данных
+func (h *Handler) trackUserStatus(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		err := next(c)
+		if err == nil || c.Chat() == nil {
+			return err
+		}
+
+		status, ok := statusFromSendError(err)
+		if !ok {
+			return err
+		}
+
+		ctx, cancel := h.requestContext(c)
+		defer cancel()
+		h.markUser(ctx, c.Chat().ID, status)
+		return nil
+	}
+}
+
+// handleMyChatMember обработчик изменения статуса бота в чате с пользователем
+func (h *Handler) handleMyChatMember(c tele.Context) error {
+	update := c.ChatMember()
+	if update == nil || update.NewChatMember == nil || update.Chat == nil || update.Chat.Type != tele.ChatPrivate {
+		return nil
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	switch update.NewChatMember.Role {
+	case tele.Kicked, tele.Left:
+		h.markUser(ctx, update.Chat.ID, models.UserStatusBlocked)
+	case tele.Member:
+		h.log.InfoContext(ctx, "user unblocked bot", slog.Int64("chat_id", update.Chat.ID))
+		if err := h.service.TouchUser(ctx, update.Chat.ID); err != nil {
+			h.log.ErrorContext(ctx, "failed to touch user", logger.Err(err))
+		}
+	}
+	return nil
+}
+
+// notify отправляет сообщение пользователю вне контекста обновления (рассылки, напоминания).
+// Если пользователь заблокировал бота или удалил аккаунт, его статус обновляется в базе данных
+func (h *Handler) notify(ctx context.Context, chatID int64, what interface{}, opts ...interface{}) error {
+	_, err := h.bot.Send(&tele.Chat{ID: chatID}, what, opts...)
+	if err == nil {
+		return nil
+	}
+
+	if status, ok := statusFromSendError(err); ok {
+		h.markUser(ctx, chatID, status)
+	}
+	return err
+}
+
+// markUser сохраняет новый статус пользователя
+func (h *Handler) markUser(ctx context.Context, chatID int64, status models.UserStatus) {
+	h.log.InfoContext(ctx, "updating user status", slog.Int64("chat_id", chatID), slog.String("status", string(status)))
+	if err := h.service.UpdateUserStatus(ctx, chatID, status); err != nil {
+		h.log.ErrorContext(ctx, "failed to update user status", logger.Err(err))
+	}
+}
+
+// statusFromSendError определяет статус пользователя по ошибке Telegram Bot API
+func statusFromSendError(err error) (models.UserStatus, bool) {
+	switch {
+	case errors.Is(err, tele.ErrBlockedByUser):
+		return models.UserStatusBlocked, true
+	case errors.Is(err, tele.ErrUserIsDeactivated):
+		return models.UserStatusDeactivated, true
+	}
+	return "", false
+}
+
+// isAdmin проверяет, является ли пользователь администратором бота
+func (h *Handler) isAdmin(c tele.Context) bool {
+	if c.Sender() == nil {
+		return false
+	}
+	_, ok := h.settings().admins[c.Sender().ID]
+	return ok
+}
+
+// isStaff проверяет, может ли пользователь отмечать посещение событий (сотрудники и администраторы)
+func (h *Handler) isStaff(c tele.Context) bool {
+	if c.Sender() == nil {
+		return false
+	}
+	_, ok := h.settings().staff[c.Sender().ID]
+	return ok || h.isAdmin(c)
+}
+
+// stats обработчик для команды /stats, доступен только администраторам
+func (h *Handler) stats(c tele.Context) error {
+	if !h.isAdmin(c) {
+		return nil
+	}
+
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	stats, err := h.service.GetUserStats(ctx)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to get user stats", logger.Err(err))
+		return c.Send("Ошибка при получении статистики")
+	}
+
+	total := 0
+	for _, count := range stats {
+		total += count
+	}
+
+	return c.Send(fmt.Sprintf(
+		"*Пользователи:* %d\n\nАктивные: %d\nЗаблокировали бота: %d\nУдалили аккаунт: %d",
+		total,
+		stats[models.UserStatusActive],
+		stats[models.UserStatusBlocked],
+		stats[models.UserStatusDeactivated],
+	), &tele.SendOptions{ParseMode: tele.ModeMarkdown})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+func TestStatusFromSendError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus models.UserStatus
+		wantOK     bool
+	}{
+		{name: "blocked", err: tele.ErrBlockedByUser, wantStatus: models.UserStatusBlocked, wantOK: true},
+		{name: "deactivated", err: tele.ErrUserIsDeactivated, wantStatus: models.UserStatusDeactivated, wantOK: true},
+		{name: "wrapped", err: fmt.Errorf("send: %w", tele.ErrBlockedByUser), wantStatus: models.UserStatusBlocked, wantOK: true},
+		{name: "chat not found", err: tele.ErrChatNotFound},
+		{name: "kicked from group", err: tele.ErrKickedFromGroup},
+		{name: "flood", err: tele.FloodError{RetryAfter: 5}},
+		{name: "network", err: errors.New("connection reset by peer")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, ok := statusFromSendError(tt.err)
+			if ok != tt.wantOK || status != tt.wantStatus {
+				t.Errorf("statusFromSendError(%v) = (%q, %v), want (%q, %v)", tt.err, status, ok, tt.wantStatus, tt.wantOK)
+			}
+		})
+	}
+}
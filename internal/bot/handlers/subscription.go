@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// announcementDescriptionLength сколько символов описания события показывается в анонсе
+const announcementDescriptionLength = 300
+
+// subscribe обработчик для команды /subscribe [ключевые слова через запятую]: включает или выключает
+// анонсы новых событий, с ключевыми словами - подписывает только на подходящие события
+func (h *Handler) subscribe(c tele.Context) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	sub, err := h.service.ToggleSubscription(ctx, c.Chat().ID, strings.TrimSpace(c.Message().Payload))
+	switch {
+	case errors.Is(err, models.ErrInvalidKeywords):
+		return c.Send("Укажите не больше 10 ключевых слов через запятую, например: /subscribe концерт, джаз")
+	case err != nil:
+		h.log.ErrorContext(ctx, "failed to toggle subscription", logger.Err(err))
+		return c.Send("Не удалось изменить подписку, попробуйте чуть позже.")
+	}
+
+	switch {
+	case !sub.Active:
+		return c.Send("Подписка на новые события отключена.")
+	case len(sub.Keywords) > 0:
+		return c.Send("Пришлю анонсы новых событий, в которых упоминается: " + strings.Join(sub.Keywords, ", ") +
+			".\n\nОтключить подписку: /subscribe")
+	default:
+		return c.Send("Пришлю анонсы всех новых событий. Чтобы получать только интересные, укажите ключевые слова: " +
+			"/subscribe концерт, джаз\n\nОтключить подписку: /subscribe")
+	}
+}
+
+// AnnounceNewEvents ставит в очередь анонсы новых событий и рассылает их подписчикам
+func (h *Handler) AnnounceNewEvents(ctx context.Context) {
+	if err := h.service.QueueNewEventAnnouncements(ctx); err != nil {
+		h.log.ErrorContext(ctx, "failed to queue new event announcements", logger.Err(err))
+	}
+	h.sendNotifications(ctx)
+}
+
+// formatAnnouncement форматирует анонс нового события, время показывается в часовом поясе пользователя
+func formatAnnouncement(a models.EventAnnouncement, loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Новое событие: «%s»", a.Title)
+	if !a.StartsAt.IsZero() {
+		fmt.Fprintf(&b, "\n\nНачало: %s", a.StartsAt.In(loc).Format("02.01.2006 15:04"))
+	}
+	if description := strings.TrimSpace(a.Description); description != "" {
+		if utf8.RuneCountInString(description) > announcementDescriptionLength {
+			description = string([]rune(description)[:announcementDescriptionLength]) + "…"
+		}
+		b.WriteString("\n\n" + description)
+	}
+	return b.String()
+}
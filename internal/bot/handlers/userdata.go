@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"log/slog"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/bot/keyboard"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	tele "gopkg.in/telebot.v3"
+)
+
+// myData обработчик для команды /mydata, отправляет пользователю JSON-файл с его данными
+func (h *Handler) myData(c tele.Context) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	chatID := c.Chat().ID
+	h.log.InfoContext(ctx, "exporting user data", slog.Int64("chat_id", chatID))
+
+	data, err := h.service.ExportUserData(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to export user data", logger.Err(err))
+		return c.Send("Не удалось выгрузить данные, попробуйте позже.")
+	}
+
+	return c.Send(&tele.Document{
+		File:     tele.FromReader(bytes.NewReader(data)),
+		FileName: "mydata.json",
+		Caption:  "Данные, которые бот хранит о вас",
+	})
+}
+
+// deleteMe обработчик для команды /deleteme, запрашивает подтверждение удаления данных
+func (h *Handler) deleteMe(c tele.Context) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	chatID := c.Chat().ID
+	h.log.InfoContext(ctx, "user requested data deletion", slog.Int64("chat_id", chatID))
+
+	if err := h.service.RequestUserDeletion(ctx, chatID); err != nil {
+		h.log.ErrorContext(ctx, "failed to save deletion request", logger.Err(err))
+		return c.Send("Произошла ошибка.")
+	}
+
+	return c.Send(
+		"Вы уверены, что хотите удалить все свои данные? Это действие нельзя отменить.",
+		keyboard.DeleteMeKeyboard(),
+	)
+}
+
+// confirmDeleteMe обрабатывает ответ пользователя на запрос подтверждения удаления данных
+func (h *Handler) confirmDeleteMe(c tele.Context, answer string) error {
+	ctx, cancel := h.requestContext(c)
+	defer cancel()
+
+	chatID := c.Chat().ID
+
+	if answer != "confirm" {
+		if err := h.service.CancelUserDeletion(ctx, chatID); err != nil {
+			h.log.ErrorContext(ctx, "failed to save deletion cancel", logger.Err(err))
+		}
+		return c.Edit("Удаление данных отменено.")
+	}
+
+	registrationsDeleted, err := h.service.DeleteUserData(ctx, chatID)
+	if err != nil {
+		h.log.ErrorContext(ctx, "failed to delete user data", logger.Err(err))
+		return c.Edit("Не удалось удалить данные, попробуйте позже.")
+	}
+	h.forgetChat(chatID)
+
+	h.log.InfoContext(ctx, "user data deleted", slog.Int64("chat_id", chatID), slog.Bool("registrations_deleted", registrationsDeleted))
+	if !registrationsDeleted {
+		return c.Edit("Данные, которые хранит бот, удалены. Регистрации на события хранятся в сервисе событий и не были удалены, " +
+			"для их удаления обратитесь к организаторам. Чтобы снова пользоваться ботом, отправьте /start.")
+	}
+	return c.Edit("Ваши данные удалены. Чтобы снова пользоваться ботом, отправьте /start.")
+}
+
+// forgetChat сбрасывает состояние диалога с пользователем, удалившим свои данные
+func (h *Handler) forgetChat(chatID int64) {
+	h.mu.Lock()
+	delete(h.checkInMode, chatID)
+	h.mu.Unlock()
+
+	h.pendingComments.delete(chatID)
+	h.searches.delete(chatID)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfirmDeleteMeForgetsChatState(t *testing.T) {
+	const chatID = 1
+
+	h, b, api := newTestHandler(t, &fakeService{})
+	h.mu.Lock()
+	h.checkInMode[chatID] = struct{}{}
+	h.mu.Unlock()
+	h.pendingComments.set(chatID, "event-1", time.Now())
+	h.searches.set(chatID, "концерт", time.Now())
+
+	b.ProcessUpdate(callbackUpdate("cb-1", 5, "deleteme:confirm"))
+
+	if api.count("editMessageText") != 1 {
+		t.Fatal("deletion result was not shown")
+	}
+	if h.inCheckInMode(chatID) {
+		t.Error("check-in mode kept after deletion")
+	}
+	if _, ok := h.takePendingComment(chatID); ok {
+		t.Error("pending comment kept after deletion")
+	}
+	if _, ok := h.searches.get(chatID, time.Now()); ok {
+		t.Error("search query kept after deletion")
+	}
+}
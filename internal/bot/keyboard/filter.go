@@ -0,0 +1,111 @@
+package keyboard
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// filterButtons кнопки быстрых фильтров событий по дате
+var filterButtons = []struct {
+	code  string
+	title string
+}{
+	{models.FilterToday, "Сегодня"},
+	{models.FilterWeek, "Неделя"},
+	{models.FilterWeekend, "Выходные"},
+	{models.FilterMonth, "Месяц"},
+}
+
+// monthNames названия месяцев для заголовка календаря
+var monthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+// calendarDateLayout формат дат в callback'ах календаря
+const calendarDateLayout = "20060102"
+
+// FilteredEventsKeyboard Inline-клавиатура, отображает список событий с рядами фильтров по дате.
+// Активный фильтр отмечается галочкой и сохраняется при листании страниц
+func FilteredEventsKeyboard(events []EventButton, activeFilter string, numPage, pageSize, countEvents int) *tele.ReplyMarkup {
+	kb := EventsKeyboard(events, "fpage:"+activeFilter, numPage, pageSize, countEvents)
+
+	quick := make([]tele.InlineButton, 0, len(filterButtons))
+	for _, f := range filterButtons {
+		text := f.title
+		if f.code == activeFilter {
+			text = "✓ " + text
+		}
+		quick = append(quick, tele.InlineButton{Text: text, Data: "filter:" + f.code})
+	}
+
+	allText := "Все"
+	if activeFilter == models.FilterAll || activeFilter == "" {
+		allText = "✓ " + allText
+	}
+	custom := []tele.InlineButton{
+		{Text: "📅 Выбрать даты", Data: "cal:m:" + time.Now().Format("200601") + ":"},
+		{Text: allText, Data: "filter:" + models.FilterAll},
+	}
+
+	kb.InlineKeyboard = append([][]tele.InlineButton{quick, custom}, kb.InlineKeyboard...)
+	return kb
+}
+
+// CalendarKeyboard Inline-клавиатура с календарём на месяц для выбора произвольного диапазона дат.
+// from - уже выбранная начальная дата в формате 20060102 либо пустая строка
+func CalendarKeyboard(month time.Time, from string) *tele.ReplyMarkup {
+	kb := &tele.ReplyMarkup{}
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	noop := tele.InlineButton{Text: " ", Data: "cal:n::"}
+
+	rows := [][]tele.InlineButton{
+		{
+			{Text: "‹", Data: "cal:m:" + first.AddDate(0, -1, 0).Format("200601") + ":" + from},
+			{Text: monthNames[first.Month()-1] + " " + strconv.Itoa(first.Year()), Data: "cal:n::"},
+			{Text: "›", Data: "cal:m:" + first.AddDate(0, 1, 0).Format("200601") + ":" + from},
+		},
+	}
+
+	weekdays := make([]tele.InlineButton, 0, 7)
+	for _, d := range []string{"Пн", "Вт", "Ср", "Чт", "Пт", "Сб", "Вс"} {
+		weekdays = append(weekdays, tele.InlineButton{Text: d, Data: "cal:n::"})
+	}
+	rows = append(rows, weekdays)
+
+	// Смещение первого дня месяца относительно понедельника
+	offset := (int(first.Weekday()) + 6) % 7
+	week := make([]tele.InlineButton, 0, 7)
+	for i := 0; i < offset; i++ {
+		week = append(week, noop)
+	}
+
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		date := day.Format(calendarDateLayout)
+		text := strconv.Itoa(day.Day())
+		if date == from {
+			text = "[" + text + "]"
+		}
+		week = append(week, tele.InlineButton{Text: text, Data: "cal:d:" + date + ":" + from})
+
+		if len(week) == 7 {
+			rows = append(rows, week)
+			week = make([]tele.InlineButton, 0, 7)
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < 7 {
+			week = append(week, noop)
+		}
+		rows = append(rows, week)
+	}
+
+	rows = append(rows, []tele.InlineButton{{Text: "Отмена", Data: "filter:" + models.FilterAll}})
+
+	kb.InlineKeyboard = rows
+	return kb
+}
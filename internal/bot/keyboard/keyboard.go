@@ -19,8 +19,9 @@ func MainKeyboard() *tele.ReplyMarkup {
 	return kb
 }
 
-// EventsKeyboard Inline-клавиатура, отображает список событий
-func EventsKeyboard(events []EventButton, numPage, pageSize, countEvents int) *tele.ReplyMarkup {
+// EventsKeyboard Inline-клавиатура, отображает список событий.
+// pageAction задаёт действие кнопок навигации, чтобы листание сохраняло режим просмотра (например, результаты поиска)
+func EventsKeyboard(events []EventButton, pageAction string, numPage, pageSize, countEvents int) *tele.ReplyMarkup {
 	kb := &tele.ReplyMarkup{}
 
 	var rows [][]tele.InlineButton
@@ -30,6 +31,10 @@ func EventsKeyboard(events []EventButton, numPage, pageSize, countEvents int) *t
 			Text: e.Title,
 			Data: "event:" + e.EventID,
 		}
+		// Карточка события из результатов поиска возвращает к той же странице результатов, а не к общему списку
+		if pageAction == "searchpage" {
+			btn.Data += ":searchpage:" + strconv.Itoa(numPage)
+		}
 		rows = append(rows, []tele.InlineButton{btn})
 	}
 
@@ -39,14 +44,14 @@ func EventsKeyboard(events []EventButton, numPage, pageSize, countEvents int) *t
 		if numPage > 0 {
 			navRow = append(navRow, tele.InlineButton{
 				Text: "Назад",
-				Data: "page:" + strconv.Itoa(numPage-1),
+				Data: pageAction + ":" + strconv.Itoa(numPage-1),
 			})
 		}
 
 		if (numPage+1)*pageSize < countEvents {
 			navRow = append(navRow, tele.InlineButton{
 				Text: "Вперёд",
-				Data: "page:" + strconv.Itoa(numPage+1),
+				Data: pageAction + ":" + strconv.Itoa(numPage+1),
 			})
 		}
 
@@ -61,12 +66,30 @@ func EventsKeyboard(events []EventButton, numPage, pageSize, countEvents int) *t
 
 // EventDetailKeyboard Inline-клавиатура, показывает детали события, позволяет вернуться назад или зарегистрироваться
 func EventDetailKeyboard(eventID string) *tele.ReplyMarkup {
+	return eventDetailKeyboard(eventID, "back:")
+}
+
+// eventDetailKeyboard Inline-клавиатура деталей события, back - callback кнопки возврата
+func eventDetailKeyboard(eventID, back string) *tele.ReplyMarkup {
 	kb := &tele.ReplyMarkup{}
 
 	kb.InlineKeyboard = [][]tele.InlineButton{
 		{
 			{Text: "Зарегистрироваться", Data: "register:" + eventID},
-			{Text: "Назад к событиям", Data: "back:"},
+			{Text: "Назад к событиям", Data: back},
+		},
+	}
+
+	return kb
+}
+
+// ProcessingKeyboard Inline-клавиатура, заменяющая кнопки сообщения на время обработки нажатия
+func ProcessingKeyboard() *tele.ReplyMarkup {
+	kb := &tele.ReplyMarkup{}
+
+	kb.InlineKeyboard = [][]tele.InlineButton{
+		{
+			{Text: "⏳ Обработка…", Data: "noop:"},
 		},
 	}
 
@@ -85,3 +108,95 @@ func BackToSeeEvents() *tele.ReplyMarkup {
 
 	return kb
 }
+
+// OpenEventKeyboard Inline-клавиатура уведомлений о событии, открывает его карточку
+func OpenEventKeyboard(eventID string) *tele.ReplyMarkup {
+	kb := &tele.ReplyMarkup{}
+
+	kb.InlineKeyboard = [][]tele.InlineButton{
+		{
+			{Text: "Открыть событие", Data: "event:" + eventID},
+		},
+	}
+
+	return kb
+}
+
+// DeleteMeKeyboard Inline-клавиатура, запрашивает подтверждение удаления персональных данных
+func DeleteMeKeyboard() *tele.ReplyMarkup {
+	kb := &tele.ReplyMarkup{}
+
+	kb.InlineKeyboard = [][]tele.InlineButton{
+		{
+			{Text: "Да, удалить мои данные", Data: "deleteme:confirm"},
+			{Text: "Отмена", Data: "deleteme:cancel"},
+		},
+	}
+
+	return kb
+}
+
+// AdminEventsKeyboard Inline-клавиатура для администратора, позволяет выбрать событие для указанного действия
+func AdminEventsKeyboard(action string, events []EventButton) *tele.ReplyMarkup {
+	kb := &tele.ReplyMarkup{}
+
+	var rows [][]tele.InlineButton
+	for _, e := range events {
+		rows = append(rows, []tele.InlineButton{{Text: e.Title, Data: action + ":" + e.EventID}})
+	}
+
+	kb.InlineKeyboard = rows
+	return kb
+}
+
+// FeedbackKeyboard Inline-клавиатура, позволяет оценить событие от 1 до 5
+func FeedbackKeyboard(eventID string) *tele.ReplyMarkup {
+	kb := &tele.ReplyMarkup{}
+
+	row := make([]tele.InlineButton, 0, 5)
+	for rating := 1; rating <= 5; rating++ {
+		row = append(row, tele.InlineButton{
+			Text: strconv.Itoa(rating) + "⭐",
+			Data: "feedback:" + eventID + ":" + strconv.Itoa(rating),
+		})
+	}
+
+	kb.InlineKeyboard = [][]tele.InlineButton{row}
+	return kb
+}
+
+// SkipCommentKeyboard Inline-клавиатура, позволяет не оставлять комментарий к оценке
+func SkipCommentKeyboard() *tele.ReplyMarkup {
+	kb := &tele.ReplyMarkup{}
+
+	kb.InlineKeyboard = [][]tele.InlineButton{
+		{
+			{Text: "Без комментария", Data: "feedbackskip:"},
+		},
+	}
+
+	return kb
+}
+
+// EventCardKeyboard Inline-клавиатура карточки события: регистрация и возврат к списку,
+// а также кнопки "Показать на карте" и внешней ссылки, если для события они заданы.
+// back - callback кнопки возврата, пустой - к общему списку событий
+func EventCardKeyboard(eventID string, showMap bool, url, back string) *tele.ReplyMarkup {
+	if back == "" {
+		back = "back:"
+	}
+	kb := eventDetailKeyboard(eventID, back)
+
+	var extra []tele.InlineButton
+	if showMap {
+		extra = append(extra, tele.InlineButton{Text: "Показать на карте", Data: "map:" + eventID})
+	}
+	if url != "" {
+		extra = append(extra, tele.InlineButton{Text: "Подробнее на сайте", URL: url})
+	}
+	if len(extra) > 0 {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, extra)
+	}
+
+	return kb
+}
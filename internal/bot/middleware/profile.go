@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	tele "gopkg.in/telebot.v3"
+)
+
+// ProfileSaver описывает метод для пакетного сохранения профилей пользователей
+type ProfileSaver interface {
+	SaveUsersInfo(ctx context.Context, profiles []models.UserProfile) error
+}
+
+// ProfileBatcher прослойка, которая накапливает профили пользователей из входящих обновлений
+// и периодически сохраняет их одним запросом, чтобы не писать в базу данных на каждый callback
+type ProfileBatcher struct {
+	log      *slog.Logger
+	saver    ProfileSaver
+	interval time.Duration
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	pending map[int64]models.UserProfile
+
+	// state защищает started и stopped: Stop может быть вызван, даже если Start не запускался
+	state   sync.Mutex
+	started bool
+	stopped bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewProfileBatcher конструктор для ProfileBatcher
+func NewProfileBatcher(log *slog.Logger, saver ProfileSaver, interval, timeout time.Duration) *ProfileBatcher {
+	return &ProfileBatcher{
+		log:      log,
+		saver:    saver,
+		interval: interval,
+		timeout:  timeout,
+		pending:  make(map[int64]models.UserProfile),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Middleware запоминает профиль отправителя обновления, если оно пришло из личного чата.
+// Изменения статуса бота в чате пропускаются: блокировка бота не является активностью пользователя
+func (p *ProfileBatcher) Middleware(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		if update := c.Update(); update.MyChatMember != nil || update.ChatMember != nil {
+			return next(c)
+		}
+		if sender, chat := c.Sender(), c.Chat(); sender != nil && chat != nil && chat.Type == tele.ChatPrivate {
+			p.mu.Lock()
+			p.pending[chat.ID] = models.UserProfile{
+				ChatID:       chat.ID,
+				Username:     sender.Username,
+				FirstName:    sender.FirstName,
+				LastName:     sender.LastName,
+				LanguageCode: sender.LanguageCode,
+			}
+			p.mu.Unlock()
+		}
+		return next(c)
+	}
+}
+
+// Start периодически сохраняет накопленные профили, пока не будет вызван Stop.
+// Последнее сохранение при остановке выполняется и после отмены ctx, чтобы не потерять профили.
+// Повторный запуск и запуск после Stop ничего не делают
+func (p *ProfileBatcher) Start(ctx context.Context) {
+	p.state.Lock()
+	if p.started || p.stopped {
+		p.state.Unlock()
+		return
+	}
+	p.started = true
+	p.state.Unlock()
+
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.flush(ctx)
+		case <-p.stop:
+			p.flush(context.WithoutCancel(ctx))
+			return
+		}
+	}
+}
+
+// Stop останавливает периодическое сохранение и сохраняет оставшиеся профили.
+// Если Start не запускался, профили сохраняются сразу; повторный вызов ничего не делает
+func (p *ProfileBatcher) Stop() {
+	p.state.Lock()
+	if p.stopped {
+		p.state.Unlock()
+		return
+	}
+	p.stopped = true
+	started := p.started
+	p.state.Unlock()
+
+	if !started {
+		p.flush(context.Background())
+		return
+	}
+	close(p.stop)
+	<-p.done
+}
+
+// flush сохраняет накопленные профили
+func (p *ProfileBatcher) flush(ctx context.Context) {
+	p.mu.Lock()
+	if len(p.pending) == 0 {
+		p.mu.Unlock()
+		return
+	}
+	profiles := make([]models.UserProfile, 0, len(p.pending))
+	for _, profile := range p.pending {
+		profiles = append(profiles, profile)
+	}
+	p.pending = make(map[int64]models.UserProfile)
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	if err := p.saver.SaveUsersInfo(ctx, profiles); err != nil {
+		p.log.ErrorContext(ctx, "failed to save user profiles", slog.Int("count", len(profiles)), logger.Err(err))
+		return
+	}
+	p.log.DebugContext(ctx, "user profiles saved", slog.Int("count", len(profiles)))
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// recordingSaver запоминает сохранённые профили
+type recordingSaver struct {
+	mu    sync.Mutex
+	saved []models.UserProfile
+}
+
+func (s *recordingSaver) SaveUsersInfo(_ context.Context, profiles []models.UserProfile) error {
+	s.mu.Lock()
+	s.saved = append(s.saved, profiles...)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *recordingSaver) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.saved)
+}
+
+func TestProfileBatcherStop(t *testing.T) {
+	tests := []struct {
+		name  string
+		start bool
+	}{
+		{name: "started", start: true},
+		{name: "never started", start: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saver := &recordingSaver{}
+			p := NewProfileBatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), saver, time.Hour, time.Second)
+			p.pending[1] = models.UserProfile{ChatID: 1}
+
+			if tt.start {
+				go p.Start(context.Background())
+			}
+
+			stopped := make(chan struct{})
+			go func() {
+				p.Stop()
+				p.Stop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+			case <-time.After(time.Second):
+				t.Fatal("Stop did not return")
+			}
+			if got := saver.count(); got != 1 {
+				t.Errorf("saved %d profiles, want 1", got)
+			}
+
+			// Start после Stop не запускает сохранение заново
+			started := make(chan struct{})
+			go func() {
+				p.Start(context.Background())
+				close(started)
+			}()
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				t.Fatal("Start after Stop did not return")
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	// strikeWindow период, в течение которого накапливаются превышения лимита пользователем
+	strikeWindow = time.Minute
+	// sweepInterval период очистки состояний пользователей, давно не присылавших обновления
+	sweepInterval = 10 * time.Minute
+)
+
+// Тексты уведомлений для ограниченных пользователей
+const (
+	userThrottledText   = "Слишком много запросов, подождите пару секунд 🙏"
+	globalThrottledText = "Бот сейчас перегружен, попробуйте чуть позже 🙏"
+)
+
+// bucket token bucket: пополняется со скоростью rate токенов в секунду до burst токенов
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take пополняет bucket на прошедшее с последнего обращения время и забирает токен, если он есть
+func (b *bucket) take(now time.Time, rate float64, burst int) bool {
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// userLimit состояние ограничения одного пользователя
+type userLimit struct {
+	bucket
+	// strikes количество превышений лимита с начала strikesSince
+	strikes      int
+	strikesSince time.Time
+	// bannedUntil время, до которого обновления пользователя игнорируются
+	bannedUntil time.Time
+}
+
+// RateLimiter прослойка, ограничивающая частоту обновлений от каждого пользователя и от всех пользователей вместе,
+// чтобы нажатия на кнопки не превращались в шквал gRPC-запросов. Пользователи, раз за разом превышающие лимит,
+// временно игнорируются
+type RateLimiter struct {
+	log          *slog.Logger
+	userRate     float64
+	userBurst    int
+	globalRate   float64
+	globalBurst  int
+	banThreshold int
+	banDuration  time.Duration
+
+	mu        sync.Mutex
+	global    bucket
+	users     map[int64]*userLimit
+	lastSweep time.Time
+}
+
+// NewRateLimiter конструктор для RateLimiter. Лимиты задаются в обновлениях в секунду,
+// banThreshold - число превышений лимита за минуту, после которого пользователь игнорируется в течение banDuration
+func NewRateLimiter(log *slog.Logger, userRate float64, userBurst int, globalRate float64, globalBurst, banThreshold int, banDuration time.Duration) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		log:          log,
+		userRate:     userRate,
+		userBurst:    userBurst,
+		globalRate:   globalRate,
+		globalBurst:  globalBurst,
+		banThreshold: banThreshold,
+		banDuration:  banDuration,
+		global:       bucket{tokens: float64(globalBurst), last: now},
+		users:        make(map[int64]*userLimit),
+		lastSweep:    now,
+	}
+}
+
+// SetLimits заменяет лимиты, уже накопленные токены и запреты пользователей сохраняются
+func (r *RateLimiter) SetLimits(userRate float64, userBurst int, globalRate float64, globalBurst, banThreshold int, banDuration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.userRate, r.userBurst = userRate, userBurst
+	r.globalRate, r.globalBurst = globalRate, globalBurst
+	r.banThreshold, r.banDuration = banThreshold, banDuration
+	r.log.Info("rate limits updated",
+		slog.Float64("user_rps", userRate), slog.Int("user_burst", userBurst),
+		slog.Float64("global_rps", globalRate), slog.Int("global_burst", globalBurst),
+	)
+}
+
+// verdict результат проверки обновления
+type verdict int
+
+const (
+	allowed verdict = iota
+	userThrottled
+	globalThrottled
+	ignored
+)
+
+// Middleware пропускает обновление дальше, если лимиты не превышены. Иначе отвечает на callback
+// мягким уведомлением, а на сообщение - только при первом превышении, чтобы не отвечать флудом на флуд.
+// Изменения статуса бота в чате не ограничиваются: их присылает Telegram, а не пользователь,
+// и отвечать на них сообщением нельзя - бот мог быть заблокирован
+func (r *RateLimiter) Middleware(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		if update := c.Update(); update.MyChatMember != nil || update.ChatMember != nil {
+			return next(c)
+		}
+
+		sender := c.Sender()
+		if sender == nil {
+			return next(c)
+		}
+
+		v, strikes := r.check(sender.ID, time.Now())
+		switch v {
+		case allowed:
+			return next(c)
+		case ignored:
+			return nil
+		}
+
+		text := userThrottledText
+		if v == globalThrottled {
+			text = globalThrottledText
+		}
+		if c.Callback() != nil {
+			return c.Respond(&tele.CallbackResponse{Text: text})
+		}
+		if v == globalThrottled || strikes == 1 {
+			return c.Send(text)
+		}
+		return nil
+	}
+}
+
+// check применяет лимиты к обновлению пользователя userID и возвращает результат и число его недавних превышений
+func (r *RateLimiter) check(userID int64, now time.Time) (verdict, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(now)
+
+	u, ok := r.users[userID]
+	if !ok {
+		u = &userLimit{bucket: bucket{tokens: float64(r.userBurst), last: now}}
+		r.users[userID] = u
+	}
+
+	if now.Before(u.bannedUntil) {
+		return ignored, u.strikes
+	}
+
+	if !u.take(now, r.userRate, r.userBurst) {
+		if now.Sub(u.strikesSince) > strikeWindow {
+			u.strikes, u.strikesSince = 0, now
+		}
+		u.strikes++
+		if r.banThreshold > 0 && u.strikes >= r.banThreshold {
+			u.bannedUntil = now.Add(r.banDuration)
+			u.strikes = 0
+			r.log.Warn("user temporarily ignored for flooding",
+				slog.Int64("user_id", userID),
+				slog.Duration("duration", r.banDuration),
+			)
+			return ignored, 0
+		}
+		return userThrottled, u.strikes
+	}
+
+	if !r.global.take(now, r.globalRate, r.globalBurst) {
+		r.log.Warn("global rate limit exceeded", slog.Int64("user_id", userID))
+		return globalThrottled, u.strikes
+	}
+
+	return allowed, u.strikes
+}
+
+// sweep удаляет состояния пользователей, у которых bucket давно полон и нет действующего запрета
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < sweepInterval {
+		return
+	}
+	r.lastSweep = now
+	for id, u := range r.users {
+		if now.Sub(u.last) > sweepInterval && now.After(u.bannedUntil) {
+			delete(r.users, id)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+func TestRateLimiterCheck(t *testing.T) {
+	type step struct {
+		user   int64
+		after  time.Duration
+		want   verdict
+		strike int
+	}
+
+	tests := []struct {
+		name         string
+		userRate     float64
+		userBurst    int
+		globalRate   float64
+		globalBurst  int
+		banThreshold int
+		banDuration  time.Duration
+		steps        []step
+	}{
+		{
+			name:     "burst then throttled",
+			userRate: 1, userBurst: 3, globalRate: 100, globalBurst: 100,
+			steps: []step{
+				{user: 1, want: allowed},
+				{user: 1, want: allowed},
+				{user: 1, want: allowed},
+				{user: 1, want: userThrottled, strike: 1},
+				{user: 1, want: userThrottled, strike: 2},
+			},
+		},
+		{
+			name:     "tokens refill over time",
+			userRate: 1, userBurst: 1, globalRate: 100, globalBurst: 100,
+			steps: []step{
+				{user: 1, want: allowed},
+				{user: 1, after: 500 * time.Millisecond, want: userThrottled, strike: 1},
+				{user: 1, after: time.Second, want: allowed, strike: 1},
+			},
+		},
+		{
+			name:     "users are limited independently",
+			userRate: 1, userBurst: 1, globalRate: 100, globalBurst: 100,
+			steps: []step{
+				{user: 1, want: allowed},
+				{user: 1, want: userThrottled, strike: 1},
+				{user: 2, want: allowed},
+			},
+		},
+		{
+			name:     "global limit",
+			userRate: 10, userBurst: 10, globalRate: 0.001, globalBurst: 2,
+			steps: []step{
+				{user: 1, want: allowed},
+				{user: 2, want: allowed},
+				{user: 3, want: globalThrottled},
+			},
+		},
+		{
+			name:     "flooding user is ignored until the ban ends",
+			userRate: 1, userBurst: 1, globalRate: 100, globalBurst: 100, banThreshold: 3, banDuration: time.Minute,
+			steps: []step{
+				{user: 1, want: allowed},
+				{user: 1, want: userThrottled, strike: 1},
+				{user: 1, want: userThrottled, strike: 2},
+				{user: 1, want: ignored},
+				{user: 1, after: 30 * time.Second, want: ignored},
+				{user: 1, after: 31 * time.Second, want: allowed},
+			},
+		},
+		{
+			name:     "strikes expire after the window",
+			userRate: 1, userBurst: 1, globalRate: 100, globalBurst: 100, banThreshold: 3, banDuration: time.Minute,
+			steps: []step{
+				{user: 1, want: allowed},
+				{user: 1, want: userThrottled, strike: 1},
+				{user: 1, want: userThrottled, strike: 2},
+				{user: 1, after: strikeWindow + time.Second, want: allowed, strike: 2},
+				{user: 1, want: userThrottled, strike: 1},
+			},
+		},
+		{
+			name:     "zero threshold disables bans",
+			userRate: 1, userBurst: 1, globalRate: 100, globalBurst: 100, banDuration: time.Minute,
+			steps: []step{
+				{user: 1, want: allowed},
+				{user: 1, want: userThrottled, strike: 1},
+				{user: 1, want: userThrottled, strike: 2},
+				{user: 1, want: userThrottled, strike: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRateLimiter(slog.New(slog.NewTextHandler(io.Discard, nil)),
+				tt.userRate, tt.userBurst, tt.globalRate, tt.globalBurst, tt.banThreshold, tt.banDuration)
+
+			now := time.Now()
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				got, strikes := r.check(s.user, now)
+				if got != s.want || strikes != s.strike {
+					t.Fatalf("step %d: got (%d, %d strikes), want (%d, %d strikes)", i, got, strikes, s.want, s.strike)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterSetLimitsKeepsBans(t *testing.T) {
+	r := NewRateLimiter(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 1, 100, 100, 2, time.Minute)
+
+	now := time.Now()
+	r.check(1, now)
+	r.check(1, now)
+	if got, _ := r.check(1, now); got != ignored {
+		t.Fatalf("got %d, want user to be banned", got)
+	}
+
+	r.SetLimits(100, 100, 100, 100, 0, 0)
+	if got, _ := r.check(1, now.Add(time.Second)); got != ignored {
+		t.Errorf("got %d, want ban to survive limit update", got)
+	}
+	if got, _ := r.check(2, now.Add(time.Second)); got != allowed {
+		t.Errorf("got %d, want new limits for other users", got)
+	}
+}
+
+func TestRateLimiterSkipsChatMemberUpdates(t *testing.T) {
+	r := NewRateLimiter(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 1, 100, 100, 0, 0)
+	b, err := tele.NewBot(tele.Settings{Offline: true})
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+
+	handled := 0
+	handler := r.Middleware(func(tele.Context) error {
+		handled++
+		return nil
+	})
+
+	// Пользователь заблокировал бота: ответ на это обновление не дошёл бы, а лимит не должен расходоваться
+	update := tele.Update{MyChatMember: &tele.ChatMemberUpdate{
+		Chat:   &tele.Chat{ID: 1, Type: tele.ChatPrivate},
+		Sender: &tele.User{ID: 1},
+	}}
+	for i := range 3 {
+		if err := handler(b.NewContext(update)); err != nil {
+			t.Fatalf("update %d: %v", i, err)
+		}
+	}
+	if handled != 3 {
+		t.Errorf("handled %d updates, want 3", handled)
+	}
+	if got, _ := r.check(1, time.Now()); got != allowed {
+		t.Errorf("got %d, want user limit untouched", got)
+	}
+}
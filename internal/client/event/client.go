@@ -5,12 +5,14 @@ import (
 	"log/slog"
 
 	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
 	opNewClient = "event.NewClient"
+	opClose     = "event.Close"
 )
 
 // Client описывает gRPC-клиент для взаимодействия с микросервисом событий
@@ -25,7 +27,7 @@ func NewClient(log *slog.Logger, address string) (*Client, error) {
 	// Устанавливаем gRPC-соединение
 	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		log.Error("error", err.Error(), slog.String("operation", opNewClient))
+		log.Error("operation failed", logger.Err(err), slog.String("operation", opNewClient))
 		return nil, fmt.Errorf("%s: %w", opNewClient, err)
 	}
 
@@ -35,3 +37,11 @@ func NewClient(log *slog.Logger, address string) (*Client, error) {
 		conn:   conn,
 	}, nil
 }
+
+// Close закрывает соединение с микросервисом событий
+func (c *Client) Close() {
+	c.log.Info("close grpc connection..", slog.String("operation", opClose))
+	if err := c.conn.Close(); err != nil {
+		c.log.Error("closing grpc connection", logger.Err(err))
+	}
+}
@@ -2,34 +2,41 @@ package event
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 
 	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // Константы для описания операций
 const (
-	opGetEvents    = "event.GetEvents"
-	opGetEvent     = "event.GetEvent"
-	opRegisterUser = "event.RegisterUser"
+	opGetEvents               = "event.GetEvents"
+	opGetEvent                = "event.GetEvent"
+	opRegisterUser            = "event.RegisterUser"
+	opDeleteUserRegistrations = "event.DeleteUserRegistrations"
+	opGetRegistrants          = "event.GetRegistrants"
 )
 
-// Кастомная ошибка
-var ErrUserAlreadyExists = errors.New("user is already registered")
+// idempotencyKeyHeader заголовок gRPC-метаданных с ключом идемпотентности запроса
+const idempotencyKeyHeader = "idempotency-key"
+
+// ErrUserAlreadyExists пользователь уже зарегистрирован на событие
+var ErrUserAlreadyExists = models.ErrAlreadyRegistered
 
 // GetEvents метод для получения всех событий
 func (c *Client) GetEvents(ctx context.Context) ([]*pb.Event, error) {
 	// Отправляем запрос на другой микросервис
 	response, err := c.client.GetEvents(ctx, &pb.GetEventsRequest{})
 	if err != nil {
-		c.log.Error("error", err.Error(), slog.String("operation", opGetEvents))
+		c.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opGetEvents))
 		return nil, fmt.Errorf("%s: %w", opGetEvents, err)
 	}
-	c.log.Info("getting events successfully", slog.Int("count", len(response.Events)), slog.String("operation", opGetEvents))
+	c.log.InfoContext(ctx, "getting events successfully", slog.Int("count", len(response.Events)), slog.String("operation", opGetEvents))
 	return response.GetEvents(), nil
 }
 
@@ -37,26 +44,54 @@ func (c *Client) GetEvents(ctx context.Context) ([]*pb.Event, error) {
 func (c *Client) GetEvent(ctx context.Context, eventID string) (*pb.Event, error) {
 	response, err := c.client.GetEvent(ctx, &pb.GetEventRequest{EventId: eventID})
 	if err != nil {
-		c.log.Error("error", err.Error(), slog.String("operation", opGetEvent))
+		c.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opGetEvent))
 		return nil, fmt.Errorf("%s: %w", opGetEvent, err)
 	}
-	c.log.Info("getting event successfully", slog.String("event_id", eventID), slog.String("operation", opGetEvent))
+	c.log.InfoContext(ctx, "getting event successfully", slog.String("event_id", eventID), slog.String("operation", opGetEvent))
 	return response.GetEvent(), nil
 }
 
-// RegisterUser метод для регистрации пользователя на конкретное событие
-func (c *Client) RegisterUser(ctx context.Context, eventID string, chatID int64, username string) (bool, error) {
+// RegisterUser метод для регистрации пользователя на конкретное событие.
+// Контракт RegisterUserRequest не содержит поля для ключа идемпотентности, поэтому он передаётся в метаданных запроса
+func (c *Client) RegisterUser(ctx context.Context, eventID string, chatID int64, username, idempotencyKey string) (bool, error) {
+	if idempotencyKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, idempotencyKeyHeader, idempotencyKey)
+	}
 	response, err := c.client.RegisterUser(ctx, &pb.RegisterUserRequest{EventId: eventID, ChatId: chatID, Username: username})
 	if err != nil {
 		if status, ok := status.FromError(err); ok {
 			switch status.Code() {
 			case codes.AlreadyExists:
 				return false, ErrUserAlreadyExists
+			case codes.Unavailable, codes.DeadlineExceeded:
+				// Запрос можно повторить позже, вызывающая сторона сама решает, ставить ли его в очередь
+				c.log.WarnContext(ctx, "event service is unavailable", logger.Err(err), slog.String("operation", opRegisterUser))
+				return false, fmt.Errorf("%s: %w: %w", opRegisterUser, models.ErrEventServiceUnavailable, err)
+			case codes.InvalidArgument, codes.NotFound, codes.PermissionDenied, codes.FailedPrecondition:
+				// Повторять такой запрос бессмысленно: событие не найдено или регистрация на него запрещена
+				c.log.WarnContext(ctx, "registration rejected by event service", logger.Err(err), slog.String("operation", opRegisterUser))
+				return false, fmt.Errorf("%s: %w: %w", opRegisterUser, models.ErrRequestRejected, err)
 			}
 		}
-		c.log.Error("error", err.Error(), slog.String("operation", opRegisterUser))
+		c.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opRegisterUser))
 		return false, fmt.Errorf("%s: %w", opRegisterUser, err)
 	}
-	c.log.Info("register user on event successfully", slog.String("event_id", eventID), slog.String("username", username), slog.String("operation", opRegisterUser))
+	c.log.InfoContext(ctx, "register user on event successfully", slog.String("event_id", eventID), slog.String("username", username), slog.String("operation", opRegisterUser))
 	return response.GetSuccess(), nil
 }
+
+// DeleteUserRegistrations метод для удаления всех регистраций пользователя.
+// Контракт микросервиса событий (shared-proto) пока не содержит такой процедуры,
+// поэтому метод всегда возвращает models.ErrNotSupported
+func (c *Client) DeleteUserRegistrations(_ context.Context, chatID int64) error {
+	c.log.Warn("deleting registrations is not supported by event service", slog.Int64("chat_id", chatID), slog.String("operation", opDeleteUserRegistrations))
+	return fmt.Errorf("%s: %w", opDeleteUserRegistrations, models.ErrNotSupported)
+}
+
+// GetRegistrants метод для получения списка участников события.
+// Контракт микросервиса событий (shared-proto) пока не содержит такой процедуры,
+// поэтому метод всегда возвращает models.ErrNotSupported
+func (c *Client) GetRegistrants(_ context.Context, eventID string) ([]models.Registrant, error) {
+	c.log.Warn("getting registrants is not supported by event service", slog.String("event_id", eventID), slog.String("operation", opGetRegistrants))
+	return nil, fmt.Errorf("%s: %w", opGetRegistrants, models.ErrNotSupported)
+}
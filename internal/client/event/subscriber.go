@@ -0,0 +1,147 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opChanges = "event.Subscriber.Changes"
+)
+
+// SnapshotStore определяет хранилище снимков каталога событий.
+// UpdateEventSnapshots передаёт diff сохранённые снимки, заменяет их возвращёнными и вместе с ними
+// ставит в очередь уведомления о возвращённых изменениях; параллельные вызовы выполняются по очереди,
+// чтобы реплики бота не разослали одно изменение дважды
+type SnapshotStore interface {
+	UpdateEventSnapshots(ctx context.Context, diff func(prev []models.EventSnapshot) ([]models.EventSnapshot, []models.EventChange, error)) error
+}
+
+// Subscriber отслеживает изменения событий в микросервисе событий.
+// Контракт микросервиса событий (shared-proto) не содержит потоковой процедуры подписки на изменения,
+// поэтому Subscriber опрашивает GetEvents и сравнивает каталог со снимком, сохранённым в базе данных
+type Subscriber struct {
+	log    *slog.Logger
+	client *Client
+	store  SnapshotStore
+}
+
+// NewSubscriber конструктор для Subscriber
+func NewSubscriber(log *slog.Logger, client *Client, store SnapshotStore) *Subscriber {
+	return &Subscriber{
+		log:    log,
+		client: client,
+		store:  store,
+	}
+}
+
+// Changes получает каталог событий, сравнивает его с сохранённым снимком и возвращает изменения
+// времени начала, названия и статуса. Снимок обновляется вместе с постановкой уведомлений об изменениях в очередь
+// и только если сравнение выполнено полностью. При первом запуске снимок только сохраняется, изменений нет
+func (s *Subscriber) Changes(ctx context.Context) ([]models.EventChange, error) {
+	events, err := s.client.GetEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opChanges, err)
+	}
+
+	var changes []models.EventChange
+	err = s.store.UpdateEventSnapshots(ctx, func(prev []models.EventSnapshot) ([]models.EventSnapshot, []models.EventChange, error) {
+		now := time.Now()
+		if len(events) == 0 && hasUpcoming(prev, now) {
+			// Пустой каталог скорее означает сбой микросервиса событий, чем отмену всех событий сразу
+			return nil, nil, fmt.Errorf("event catalog is empty while %d events are known", len(prev))
+		}
+		var next []models.EventSnapshot
+		next, changes = diffSnapshots(prev, events, now)
+		return next, changes, nil
+	})
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opChanges))
+		return nil, fmt.Errorf("%s: %w", opChanges, err)
+	}
+
+	for _, c := range changes {
+		s.log.InfoContext(ctx, "event changed",
+			slog.String("event_id", c.New.EventID),
+			slog.String("kind", string(c.Kind)),
+			slog.String("operation", opChanges),
+		)
+	}
+	return changes, nil
+}
+
+// diffSnapshots сравнивает сохранённые снимки с каталогом событий и возвращает новые снимки и изменения.
+// Событие, пропавшее из каталога до начала, считается отменённым и хранится, пока не пройдёт время его начала;
+// пропавшее после начала или не имевшее времени начала - завершённым и просто забывается
+func diffSnapshots(prev []models.EventSnapshot, events []*pb.Event, now time.Time) ([]models.EventSnapshot, []models.EventChange) {
+	known := make(map[string]models.EventSnapshot, len(prev))
+	for _, p := range prev {
+		known[p.EventID] = p
+	}
+
+	next := make([]models.EventSnapshot, 0, len(events))
+	var changes []models.EventChange
+	seen := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		snapshot := toSnapshot(e)
+		seen[snapshot.EventID] = struct{}{}
+		next = append(next, snapshot)
+
+		old, ok := known[snapshot.EventID]
+		if !ok {
+			continue
+		}
+		change := models.EventChange{Kind: models.EventChangeUpdated, Old: old, New: snapshot}
+		switch {
+		case old.Status == models.EventStatusCancelled:
+			change.Kind = models.EventChangeRestored
+		case !change.TitleChanged() && !change.TimeChanged():
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for _, old := range prev {
+		if _, ok := seen[old.EventID]; ok || old.StartsAt.IsZero() || !old.StartsAt.After(now) {
+			continue
+		}
+		cancelled := old
+		cancelled.Status = models.EventStatusCancelled
+		next = append(next, cancelled)
+		if old.Status != models.EventStatusCancelled {
+			changes = append(changes, models.EventChange{Kind: models.EventChangeCancelled, Old: old, New: cancelled})
+		}
+	}
+	return next, changes
+}
+
+// toSnapshot преобразует событие из каталога в снимок
+func toSnapshot(e *pb.Event) models.EventSnapshot {
+	snapshot := models.EventSnapshot{
+		EventID: e.GetId(),
+		Title:   e.GetTitle(),
+		Status:  models.EventStatusScheduled,
+	}
+	if e.GetStartsAt() != nil {
+		// База данных хранит время с точностью до микросекунд, иначе каждое сравнение находило бы изменение
+		snapshot.StartsAt = e.GetStartsAt().AsTime().Truncate(time.Microsecond)
+	}
+	return snapshot
+}
+
+// hasUpcoming проверяет, есть ли среди снимков неотменённые события, которые ещё не начались
+func hasUpcoming(snapshots []models.EventSnapshot, now time.Time) bool {
+	for _, s := range snapshots {
+		if s.Status == models.EventStatusScheduled && s.StartsAt.After(now) {
+			return true
+		}
+	}
+	return false
+}
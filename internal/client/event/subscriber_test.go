@@ -0,0 +1,115 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.Add(24 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	event := func(id, title string, startsAt time.Time) *pb.Event {
+		return &pb.Event{Id: id, Title: title, StartsAt: timestamppb.New(startsAt)}
+	}
+	snapshot := func(id, title string, startsAt time.Time, status models.EventStatus) models.EventSnapshot {
+		return models.EventSnapshot{EventID: id, Title: title, StartsAt: startsAt, Status: status}
+	}
+
+	tests := []struct {
+		name        string
+		prev        []models.EventSnapshot
+		events      []*pb.Event
+		wantNext    int
+		wantChanges []models.EventChangeKind
+	}{
+		{
+			name:     "first run only remembers catalog",
+			events:   []*pb.Event{event("1", "Концерт", tomorrow)},
+			wantNext: 1,
+		},
+		{
+			name:     "unchanged event",
+			prev:     []models.EventSnapshot{snapshot("1", "Концерт", tomorrow, models.EventStatusScheduled)},
+			events:   []*pb.Event{event("1", "Концерт", tomorrow)},
+			wantNext: 1,
+		},
+		{
+			name:        "title changed",
+			prev:        []models.EventSnapshot{snapshot("1", "Концерт", tomorrow, models.EventStatusScheduled)},
+			events:      []*pb.Event{event("1", "Джазовый концерт", tomorrow)},
+			wantNext:    1,
+			wantChanges: []models.EventChangeKind{models.EventChangeUpdated},
+		},
+		{
+			name:        "time changed",
+			prev:        []models.EventSnapshot{snapshot("1", "Концерт", tomorrow, models.EventStatusScheduled)},
+			events:      []*pb.Event{event("1", "Концерт", tomorrow.Add(time.Hour))},
+			wantNext:    1,
+			wantChanges: []models.EventChangeKind{models.EventChangeUpdated},
+		},
+		{
+			name:        "upcoming event disappeared",
+			prev:        []models.EventSnapshot{snapshot("1", "Концерт", tomorrow, models.EventStatusScheduled)},
+			wantNext:    1,
+			wantChanges: []models.EventChangeKind{models.EventChangeCancelled},
+		},
+		{
+			name:     "already cancelled event is not reported again",
+			prev:     []models.EventSnapshot{snapshot("1", "Концерт", tomorrow, models.EventStatusCancelled)},
+			wantNext: 1,
+		},
+		{
+			name: "past or undated event disappeared",
+			prev: []models.EventSnapshot{
+				snapshot("1", "Концерт", yesterday, models.EventStatusScheduled),
+				snapshot("2", "Лекция", time.Time{}, models.EventStatusScheduled),
+			},
+		},
+		{
+			name:        "cancelled event returned",
+			prev:        []models.EventSnapshot{snapshot("1", "Концерт", tomorrow, models.EventStatusCancelled)},
+			events:      []*pb.Event{event("1", "Концерт", tomorrow)},
+			wantNext:    1,
+			wantChanges: []models.EventChangeKind{models.EventChangeRestored},
+		},
+		{
+			name:     "sub-microsecond difference is ignored",
+			prev:     []models.EventSnapshot{snapshot("1", "Концерт", tomorrow, models.EventStatusScheduled)},
+			events:   []*pb.Event{event("1", "Концерт", tomorrow.Add(500*time.Nanosecond))},
+			wantNext: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, changes := diffSnapshots(tt.prev, tt.events, now)
+			if len(next) != tt.wantNext {
+				t.Errorf("got %d snapshots, want %d", len(next), tt.wantNext)
+			}
+			if len(changes) != len(tt.wantChanges) {
+				t.Fatalf("got changes %+v, want kinds %v", changes, tt.wantChanges)
+			}
+			for i, change := range changes {
+				if change.Kind != tt.wantChanges[i] {
+					t.Errorf("change %d: got kind %q, want %q", i, change.Kind, tt.wantChanges[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiffSnapshotsKeepsCancelledUpcomingEvent(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	prev := []models.EventSnapshot{{EventID: "1", Title: "Концерт", StartsAt: now.Add(time.Hour), Status: models.EventStatusScheduled}}
+
+	next, _ := diffSnapshots(prev, nil, now)
+	if len(next) != 1 || next[0].Status != models.EventStatusCancelled {
+		t.Fatalf("got %+v, want the event kept as cancelled", next)
+	}
+}
@@ -2,11 +2,14 @@ package config
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
 )
 
 // Константы для описания операций
@@ -16,20 +19,46 @@ const (
 
 // Config описывает конфигурацию микросервиса
 type Config struct {
-	telegramBotConfig *telegramBotConfig
-	databaseConfig    *databaseConfig
-	gRPCClientConfig  *gRPCClientConfig
+	telegramBotConfig   *telegramBotConfig
+	databaseConfig      *databaseConfig
+	gRPCClientConfig    *gRPCClientConfig
+	ticketConfig        *ticketConfig
+	feedbackConfig      *feedbackConfig
+	registrationsConfig *registrationsConfig
+	announcementsConfig *announcementsConfig
+	rateLimitConfig     *rateLimitConfig
+	timeoutsConfig      *timeoutsConfig
+	logConfig           *logConfig
+	featuresConfig      *featuresConfig
+
+	// values итоговые значения настроек и их источники, нужны для вывода конфигурации
+	values values
+	// printOnly запрошен вывод конфигурации вместо запуска
+	printOnly bool
+	// args флаги командной строки, с которыми конфигурация загружается повторно при перезагрузке
+	args []string
 }
 
 // telegramBotConfig описывает конфигурацию телеграм-бота
 type telegramBotConfig struct {
-	token string
+	token                string
+	adminIDs             []int64
+	pollerTimeout        time.Duration
+	pageSize             int
+	welcomeMessage       string
+	profileFlushInterval time.Duration
+	defaultLocation      *time.Location
+	pastEventsGrace      time.Duration
+	updateWorkers        int
+	shutdownTimeout      time.Duration
 }
 
 // databaseConfig описывает конфигурацию базы данных
 type databaseConfig struct {
-	driverName string
-	path       string
+	driverName       string
+	path             string
+	autoMigrate      bool
+	migrationTimeout time.Duration
 }
 
 // gRPCClientConfig описывает конфигурацию gRPC-клиента
@@ -37,107 +66,297 @@ type gRPCClientConfig struct {
 	address string
 }
 
+// ticketConfig описывает конфигурацию билетов и отметки посещения событий
+type ticketConfig struct {
+	secret   string
+	staffIDs []int64
+}
+
+// feedbackConfig описывает конфигурацию опросов участников после события
+type feedbackConfig struct {
+	eventDuration time.Duration
+	checkInterval time.Duration
+}
+
+// registrationsConfig описывает конфигурацию локальной копии регистраций
+type registrationsConfig struct {
+	reconcileInterval    time.Duration
+	eventChangesInterval time.Duration
+	outboxInterval       time.Duration
+	outboxMaxAttempts    int
+	outboxRetryBackoff   time.Duration
+}
+
+// announcementsConfig описывает конфигурацию анонсов новых событий и рассылок
+type announcementsConfig struct {
+	interval      time.Duration
+	broadcastRate float64
+}
+
+// rateLimitConfig описывает конфигурацию ограничения частоты обновлений от пользователей
+type rateLimitConfig struct {
+	userRate     float64
+	userBurst    int
+	globalRate   float64
+	globalBurst  int
+	banThreshold int
+	banDuration  time.Duration
+}
+
+// timeoutsConfig описывает ограничения времени выполнения отдельных операций
+type timeoutsConfig struct {
+	request      time.Duration
+	profileFlush time.Duration
+	job          time.Duration
+}
+
+// logConfig описывает конфигурацию логирования
+type logConfig struct {
+	level   string
+	format  string
+	piiMode string
+	piiSalt string
+}
+
+// featuresConfig описывает флаги включения отдельных возможностей бота
+type featuresConfig struct {
+	search          bool
+	feedbackSurveys bool
+	// eventChangeNotices участники получают уведомления об изменении и отмене событий
+	eventChangeNotices bool
+	// registrationOutbox заявки на регистрацию откладываются в очередь, пока микросервис событий недоступен
+	registrationOutbox bool
+}
+
 // newTelegramBotConfig создаёт конфигурацию для телеграм-бота
-func newTelegramBotConfig(log *slog.Logger) (*telegramBotConfig, error) {
-	token := getEnv("TELEGRAM_BOT_TOKEN", "")
-	if token == "" {
-		log.Error("telegram-bot token cannot be empty")
-		return nil, errors.New("telegram-bot token cannot be empty")
+func newTelegramBotConfig(p *parser) *telegramBotConfig {
+	return &telegramBotConfig{
+		token:                p.required("TELEGRAM_BOT_TOKEN"),
+		adminIDs:             p.ids("ADMIN_IDS"),
+		pollerTimeout:        p.positiveDuration("POLLER_TIMEOUT"),
+		pageSize:             p.positiveInt("PAGE_SIZE"),
+		welcomeMessage:       p.text("WELCOME_MESSAGE"),
+		profileFlushInterval: p.positiveDuration("PROFILE_FLUSH_INTERVAL"),
+		defaultLocation:      p.location("DEFAULT_TIMEZONE"),
+		pastEventsGrace:      p.nonNegativeDuration("PAST_EVENTS_GRACE"),
+		updateWorkers:        p.positiveInt("UPDATE_WORKERS"),
+		shutdownTimeout:      p.positiveDuration("SHUTDOWN_TIMEOUT"),
 	}
-	tgBotCfg := &telegramBotConfig{token: token}
-	return tgBotCfg, nil
 }
 
 // newDatabaseConfig создаёт конфигурацию для базы данных
-func newDatabaseConfig(log *slog.Logger) (*databaseConfig, error) {
-	path := getEnv("DSN", "")
-	if path == "" {
-		log.Error("dsn cannot be empty")
-		return nil, errors.New("dsn cannot be empty")
+func newDatabaseConfig(p *parser) *databaseConfig {
+	return &databaseConfig{
+		path:             p.required("DSN"),
+		driverName:       p.required("DB_DRIVER_NAME"),
+		autoMigrate:      p.bool("AUTO_MIGRATE"),
+		migrationTimeout: p.positiveDuration("MIGRATION_TIMEOUT"),
 	}
-	driverName := getEnv("DB_DRIVER_NAME", "")
-	if driverName == "" {
-		log.Error("database driver name cannot be empty")
-		return nil, errors.New("database driver name cannot be empty")
-	}
-	dbCfg := &databaseConfig{path: path, driverName: driverName}
-	return dbCfg, nil
 }
 
 // newGRPCClientConfig создаёт конфигурацию для gRPC-клиента
-func newGRPCClientConfig(log *slog.Logger) (*gRPCClientConfig, error) {
-	address := getEnv("GRPC_ADDRESS", "")
-	if address == "" {
-		log.Error("gRPC address cannot be empty")
-		return nil, errors.New("gRPC address cannot be empty")
+func newGRPCClientConfig(p *parser) *gRPCClientConfig {
+	return &gRPCClientConfig{address: p.required("GRPC_ADDRESS")}
+}
+
+// newTicketConfig создаёт конфигурацию билетов, при пустом секрете выпуск билетов отключён
+func newTicketConfig(p *parser) *ticketConfig {
+	return &ticketConfig{
+		secret:   p.str("TICKET_SECRET"),
+		staffIDs: p.ids("CHECKIN_STAFF_IDS"),
 	}
-	gRPCCfg := &gRPCClientConfig{address: address}
-	return gRPCCfg, nil
 }
 
-// getEnv проверяет наличие переменной окружения и возвращает её текущее значение, либо стандартное, при отсутствии текущего
-func getEnv(key, reserve string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
+// newFeedbackConfig создаёт конфигурацию опросов участников после события
+func newFeedbackConfig(p *parser) *feedbackConfig {
+	return &feedbackConfig{
+		eventDuration: p.nonNegativeDuration("FEEDBACK_EVENT_DURATION"),
+		checkInterval: p.positiveDuration("FEEDBACK_CHECK_INTERVAL"),
 	}
-	return reserve
 }
 
-// LoadConfig создаёт конфигурацию микросервиса
-func LoadConfig(log *slog.Logger) (*Config, error) {
-	log.Info("loading environment variables")
-	// Загрузка переменных окружения из .env
-	if err := godotenv.Load(); err != nil {
-		log.Error("error", err.Error(), slog.String("operation", opLoadConfig))
-		return nil, fmt.Errorf("%s: %w", opLoadConfig, err)
+// newRegistrationsConfig создаёт конфигурацию локальной копии регистраций
+func newRegistrationsConfig(p *parser) *registrationsConfig {
+	return &registrationsConfig{
+		reconcileInterval:    p.positiveDuration("RECONCILE_INTERVAL"),
+		eventChangesInterval: p.positiveDuration("EVENT_CHANGES_INTERVAL"),
+		outboxInterval:       p.positiveDuration("OUTBOX_INTERVAL"),
+		outboxMaxAttempts:    p.positiveInt("OUTBOX_MAX_ATTEMPTS"),
+		outboxRetryBackoff:   p.positiveDuration("OUTBOX_RETRY_BACKOFF"),
 	}
-	log.Info("environment variables successfully loaded")
+}
 
-	// Создаём конфигурацию базы данных
-	dbCfg, err := newDatabaseConfig(log)
-	if err != nil {
-		log.Error("error", err.Error(), slog.String("operation", opLoadConfig))
-		return nil, err
+// newAnnouncementsConfig создаёт конфигурацию анонсов новых событий
+func newAnnouncementsConfig(p *parser) *announcementsConfig {
+	return &announcementsConfig{
+		interval:      p.positiveDuration("ANNOUNCE_INTERVAL"),
+		broadcastRate: p.positiveFloat("BROADCAST_RATE"),
 	}
-	// Создаём конфигурацию телеграм-бота
-	tgBotCfg, err := newTelegramBotConfig(log)
-	if err != nil {
+}
 
-		return nil, err
+// newRateLimitConfig создаёт конфигурацию ограничения частоты обновлений
+func newRateLimitConfig(p *parser) *rateLimitConfig {
+	return &rateLimitConfig{
+		userRate:     p.positiveFloat("RATE_LIMIT_USER_RPS"),
+		userBurst:    p.positiveInt("RATE_LIMIT_USER_BURST"),
+		globalRate:   p.positiveFloat("RATE_LIMIT_GLOBAL_RPS"),
+		globalBurst:  p.positiveInt("RATE_LIMIT_GLOBAL_BURST"),
+		banThreshold: p.nonNegativeInt("RATE_LIMIT_BAN_THRESHOLD"),
+		banDuration:  p.positiveDuration("RATE_LIMIT_BAN_DURATION"),
 	}
-	// Создаём конфигурацию gRPC-клиента
-	gRPCCfg, err := newGRPCClientConfig(log)
+}
+
+// newTimeoutsConfig создаёт конфигурацию таймаутов операций
+func newTimeoutsConfig(p *parser) *timeoutsConfig {
+	return &timeoutsConfig{
+		request:      p.positiveDuration("REQUEST_TIMEOUT"),
+		profileFlush: p.positiveDuration("PROFILE_FLUSH_TIMEOUT"),
+		job:          p.positiveDuration("JOB_TIMEOUT"),
+	}
+}
+
+// newLogConfig создаёт конфигурацию логирования
+func newLogConfig(p *parser) *logConfig {
+	return &logConfig{
+		level:   p.oneOf("LOG_LEVEL", "debug", "info", "warn", "error"),
+		format:  p.oneOf("LOG_FORMAT", logger.FormatJSON, logger.FormatText),
+		piiMode: p.oneOf("LOG_PII", logger.PIIPlain, logger.PIIHash, logger.PIIRedact),
+		piiSalt: p.str("LOG_PII_SALT"),
+	}
+}
+
+// newFeaturesConfig создаёт конфигурацию флагов возможностей
+func newFeaturesConfig(p *parser) *featuresConfig {
+	return &featuresConfig{
+		search:             p.bool("FEATURE_SEARCH"),
+		feedbackSurveys:    p.bool("FEATURE_FEEDBACK_SURVEYS"),
+		eventChangeNotices: p.bool("FEATURE_EVENT_CHANGE_NOTICES"),
+		registrationOutbox: p.bool("FEATURE_REGISTRATION_OUTBOX"),
+	}
+}
+
+// LoadConfig создаёт конфигурацию микросервиса. Значения настроек берутся по возрастанию приоритета:
+// значения по умолчанию, YAML-файл (флаг --config или CONFIG_FILE), переменные окружения (в том числе из .env)
+// и флаги командной строки args. Ошибки всех настроек проверяются и возвращаются вместе
+func LoadConfig(log *slog.Logger, args []string) (*Config, error) {
+	log.Info("loading configuration")
+
+	vals, printOnly, fileErrs, err := loadValues(log, args)
 	if err != nil {
-		log.Error("error", err.Error(), slog.String("operation", opLoadConfig))
-		return nil, err
+		log.Error("operation failed", logger.Err(err), slog.String("operation", opLoadConfig))
+		return nil, fmt.Errorf("%s: %w", opLoadConfig, err)
+	}
+
+	// Секреты регистрируются до разбора настроек, чтобы не попасть ни в одну запись лога
+	logger.RegisterSecret(vals.secretValues()...)
+
+	p := &parser{values: vals, errs: fileErrs}
+	cfg := &Config{
+		telegramBotConfig:   newTelegramBotConfig(p),
+		databaseConfig:      newDatabaseConfig(p),
+		gRPCClientConfig:    newGRPCClientConfig(p),
+		ticketConfig:        newTicketConfig(p),
+		feedbackConfig:      newFeedbackConfig(p),
+		registrationsConfig: newRegistrationsConfig(p),
+		announcementsConfig: newAnnouncementsConfig(p),
+		rateLimitConfig:     newRateLimitConfig(p),
+		timeoutsConfig:      newTimeoutsConfig(p),
+		logConfig:           newLogConfig(p),
+		featuresConfig:      newFeaturesConfig(p),
+		values:              vals,
+		printOnly:           printOnly,
+		args:                args,
 	}
 
-	return &Config{
-		telegramBotConfig: tgBotCfg,
-		databaseConfig:    dbCfg,
-		gRPCClientConfig:  gRPCCfg,
-	}, nil
+	for _, e := range p.errs {
+		log.Error("invalid configuration", logger.Err(e), slog.String("operation", opLoadConfig))
+	}
+	// При выводе конфигурации ошибки не мешают показать итоговые значения
+	if err = errors.Join(p.errs...); err != nil && !printOnly {
+		return nil, fmt.Errorf("%s: %w", opLoadConfig, err)
+	}
+
+	if cfg.ticketConfig.secret == "" {
+		log.Warn("ticket secret is empty, tickets are disabled")
+	}
+	log.Info("configuration successfully loaded")
+	return cfg, nil
 }
 
-// MustLoadConfig обёртка для LoadConfig, при ошибке - паникует
-func MustLoadConfig(log *slog.Logger) *Config {
-	cfg, err := LoadConfig(log)
+// MustLoadConfig обёртка для LoadConfig, при ошибке завершает процесс: после --help с кодом 0,
+// при неверных флагах или настройках - с кодом 2 и списком всех ошибок
+func MustLoadConfig(log *slog.Logger, args []string) *Config {
+	cfg, err := LoadConfig(log, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 	return cfg
 }
 
+// PrintOnly сообщает, запрошен ли флагом --print-config вывод конфигурации вместо запуска
+func (c *Config) PrintOnly() bool {
+	return c.printOnly
+}
+
+// Print выводит итоговые значения всех настроек и их источники, значения секретов скрываются
+func (c *Config) Print(w io.Writer) error {
+	return c.values.print(w)
+}
+
 // GetTelegramBotToken геттер, для получения значения токена телеграм-бота
 func (c *Config) GetTelegramBotToken() string {
 	return c.telegramBotConfig.token
 }
 
+// GetAdminIDs геттер, для получения идентификаторов администраторов бота
+func (c *Config) GetAdminIDs() []int64 {
+	return c.telegramBotConfig.adminIDs
+}
+
+// GetProfileFlushInterval геттер, для получения периода пакетного сохранения профилей пользователей
+func (c *Config) GetProfileFlushInterval() time.Duration {
+	return c.telegramBotConfig.profileFlushInterval
+}
+
+// GetDefaultLocation геттер, для получения часового пояса пользователей, не указавших свой
+func (c *Config) GetDefaultLocation() *time.Location {
+	return c.telegramBotConfig.defaultLocation
+}
+
+// GetPastEventsGrace геттер, для получения времени, в течение которого начавшееся событие ещё показывается
+func (c *Config) GetPastEventsGrace() time.Duration {
+	return c.telegramBotConfig.pastEventsGrace
+}
+
+// GetUpdateWorkers геттер, для получения числа обработчиков входящих обновлений
+func (c *Config) GetUpdateWorkers() int {
+	return c.telegramBotConfig.updateWorkers
+}
+
+// GetShutdownTimeout геттер, для получения времени ожидания завершения обработчиков при остановке
+func (c *Config) GetShutdownTimeout() time.Duration {
+	return c.telegramBotConfig.shutdownTimeout
+}
+
 // GetDatabasePath геттер, для получения пути подключения к базе данных
 func (c *Config) GetDatabasePath() string {
 	return c.databaseConfig.path
 }
 
+// IsAutoMigrateEnabled геттер, возвращает true, если миграции применяются при запуске бота
+func (c *Config) IsAutoMigrateEnabled() bool {
+	return c.databaseConfig.autoMigrate
+}
+
+// GetMigrationTimeout геттер для получения ограничения времени применения миграций
+func (c *Config) GetMigrationTimeout() time.Duration {
+	return c.databaseConfig.migrationTimeout
+}
+
 // GetDatabaseDriverName геттер для получения драйвера базы данных
 func (c *Config) GetDatabaseDriverName() string {
 	return c.databaseConfig.driverName
@@ -147,3 +366,158 @@ func (c *Config) GetDatabaseDriverName() string {
 func (c *Config) GetGRPCAddress() string {
 	return c.gRPCClientConfig.address
 }
+
+// GetTicketSecret геттер, для получения секрета подписи билетов
+func (c *Config) GetTicketSecret() string {
+	return c.ticketConfig.secret
+}
+
+// GetCheckInStaffIDs геттер, для получения идентификаторов сотрудников, отмечающих посещение
+func (c *Config) GetCheckInStaffIDs() []int64 {
+	return c.ticketConfig.staffIDs
+}
+
+// GetEventDuration геттер, для получения предполагаемой длительности события
+func (c *Config) GetEventDuration() time.Duration {
+	return c.feedbackConfig.eventDuration
+}
+
+// GetFeedbackCheckInterval геттер, для получения периода проверки завершившихся событий
+func (c *Config) GetFeedbackCheckInterval() time.Duration {
+	return c.feedbackConfig.checkInterval
+}
+
+// GetReconcileInterval геттер, для получения периода сверки регистраций с микросервисом событий
+func (c *Config) GetReconcileInterval() time.Duration {
+	return c.registrationsConfig.reconcileInterval
+}
+
+// GetEventChangesInterval геттер, для получения периода проверки изменений и отмен событий
+func (c *Config) GetEventChangesInterval() time.Duration {
+	return c.registrationsConfig.eventChangesInterval
+}
+
+// GetOutboxInterval геттер, для получения периода отправки отложенных заявок на регистрацию
+func (c *Config) GetOutboxInterval() time.Duration {
+	return c.registrationsConfig.outboxInterval
+}
+
+// GetOutboxMaxAttempts геттер, для получения числа попыток отправки отложенной заявки
+func (c *Config) GetOutboxMaxAttempts() int {
+	return c.registrationsConfig.outboxMaxAttempts
+}
+
+// GetOutboxRetryBackoff геттер, для получения паузы перед первой повторной отправкой заявки
+func (c *Config) GetOutboxRetryBackoff() time.Duration {
+	return c.registrationsConfig.outboxRetryBackoff
+}
+
+// GetAnnounceInterval геттер, для получения периода проверки новых событий для анонсов
+func (c *Config) GetAnnounceInterval() time.Duration {
+	return c.announcementsConfig.interval
+}
+
+// GetBroadcastRate геттер, для получения допустимого числа сообщений в секунду при рассылках
+func (c *Config) GetBroadcastRate() float64 {
+	return c.announcementsConfig.broadcastRate
+}
+
+// GetUserRateLimit геттер, для получения допустимого числа обновлений в секунду от одного пользователя
+func (c *Config) GetUserRateLimit() float64 {
+	return c.rateLimitConfig.userRate
+}
+
+// GetUserRateBurst геттер, для получения допустимого всплеска обновлений от одного пользователя
+func (c *Config) GetUserRateBurst() int {
+	return c.rateLimitConfig.userBurst
+}
+
+// GetGlobalRateLimit геттер, для получения допустимого числа обновлений в секунду от всех пользователей
+func (c *Config) GetGlobalRateLimit() float64 {
+	return c.rateLimitConfig.globalRate
+}
+
+// GetGlobalRateBurst геттер, для получения допустимого всплеска обновлений от всех пользователей
+func (c *Config) GetGlobalRateBurst() int {
+	return c.rateLimitConfig.globalBurst
+}
+
+// GetRateLimitBanThreshold геттер, для получения числа превышений лимита за минуту, после которого пользователь игнорируется
+func (c *Config) GetRateLimitBanThreshold() int {
+	return c.rateLimitConfig.banThreshold
+}
+
+// GetRateLimitBanDuration геттер, для получения времени, в течение которого игнорируется флудящий пользователь
+func (c *Config) GetRateLimitBanDuration() time.Duration {
+	return c.rateLimitConfig.banDuration
+}
+
+// GetRequestTimeout геттер, для получения ограничения времени одной операции обработчика обновления
+func (c *Config) GetRequestTimeout() time.Duration {
+	return c.timeoutsConfig.request
+}
+
+// GetProfileFlushTimeout геттер, для получения ограничения времени пакетного сохранения профилей
+func (c *Config) GetProfileFlushTimeout() time.Duration {
+	return c.timeoutsConfig.profileFlush
+}
+
+// GetJobTimeout геттер, для получения ограничения времени одного запуска фоновой задачи
+func (c *Config) GetJobTimeout() time.Duration {
+	return c.timeoutsConfig.job
+}
+
+// GetLogLevel геттер, для получения уровня логирования
+func (c *Config) GetLogLevel() string {
+	return c.logConfig.level
+}
+
+// GetLogFormat геттер, для получения формата логов (json или text)
+func (c *Config) GetLogFormat() string {
+	return c.logConfig.format
+}
+
+// GetLogPIIMode геттер, для получения режима обработки персональных данных в логах (plain, hash или redact)
+func (c *Config) GetLogPIIMode() string {
+	return c.logConfig.piiMode
+}
+
+// GetLogPIISalt геттер, для получения соли, добавляемой к персональным данным перед хешированием
+func (c *Config) GetLogPIISalt() string {
+	return c.logConfig.piiSalt
+}
+
+// GetPollerTimeout геттер, для получения таймаута long polling запросов к Telegram
+func (c *Config) GetPollerTimeout() time.Duration {
+	return c.telegramBotConfig.pollerTimeout
+}
+
+// GetPageSize геттер, для получения количества событий на одной странице списка
+func (c *Config) GetPageSize() int {
+	return c.telegramBotConfig.pageSize
+}
+
+// GetWelcomeMessage геттер, для получения приветствия в ответ на /start
+func (c *Config) GetWelcomeMessage() string {
+	return c.telegramBotConfig.welcomeMessage
+}
+
+// IsSearchEnabled геттер, сообщает, включён ли поиск событий по тексту сообщения
+func (c *Config) IsSearchEnabled() bool {
+	return c.featuresConfig.search
+}
+
+// IsFeedbackSurveysEnabled геттер, сообщает, включены ли опросы участников после событий
+func (c *Config) IsFeedbackSurveysEnabled() bool {
+	return c.featuresConfig.feedbackSurveys
+}
+
+// IsEventChangeNoticesEnabled геттер, сообщает, получают ли участники уведомления об изменении и отмене событий
+func (c *Config) IsEventChangeNoticesEnabled() bool {
+	return c.featuresConfig.eventChangeNotices
+}
+
+// IsRegistrationOutboxEnabled геттер, сообщает, откладываются ли заявки на регистрацию при недоступности микросервиса событий
+func (c *Config) IsRegistrationOutboxEnabled() bool {
+	return c.featuresConfig.registrationOutbox
+}
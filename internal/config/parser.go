@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parser разбирает значения настроек, накапливая ошибки всех настроек, чтобы сообщить о них разом
+type parser struct {
+	values values
+	errs   []error
+}
+
+// fail запоминает ошибку настройки key
+func (p *parser) fail(key, format string, args ...any) {
+	v := p.values[key]
+	p.errs = append(p.errs, fmt.Errorf("%s (from %s): %s", key, v.source, fmt.Sprintf(format, args...)))
+}
+
+// str возвращает значение настройки как есть
+func (p *parser) str(key string) string {
+	return strings.TrimSpace(p.values[key].raw)
+}
+
+// required возвращает значение обязательной настройки
+func (p *parser) required(key string) string {
+	raw := p.str(key)
+	if raw == "" {
+		p.fail(key, "cannot be empty")
+	}
+	return raw
+}
+
+// text возвращает непустой текст сообщения, последовательность \n заменяется переносом строки
+func (p *parser) text(key string) string {
+	return strings.ReplaceAll(p.required(key), `\n`, "\n")
+}
+
+// positiveDuration разбирает положительную длительность
+func (p *parser) positiveDuration(key string) time.Duration {
+	d, err := time.ParseDuration(p.str(key))
+	if err != nil || d <= 0 {
+		p.fail(key, "must be a positive duration, got %q", p.str(key))
+	}
+	return d
+}
+
+// nonNegativeDuration разбирает неотрицательную длительность
+func (p *parser) nonNegativeDuration(key string) time.Duration {
+	d, err := time.ParseDuration(p.str(key))
+	if err != nil || d < 0 {
+		p.fail(key, "must be a non-negative duration, got %q", p.str(key))
+	}
+	return d
+}
+
+// positiveInt разбирает положительное целое число
+func (p *parser) positiveInt(key string) int {
+	n, err := strconv.Atoi(p.str(key))
+	if err != nil || n <= 0 {
+		p.fail(key, "must be a positive integer, got %q", p.str(key))
+	}
+	return n
+}
+
+// nonNegativeInt разбирает неотрицательное целое число
+func (p *parser) nonNegativeInt(key string) int {
+	n, err := strconv.Atoi(p.str(key))
+	if err != nil || n < 0 {
+		p.fail(key, "must be a non-negative integer, got %q", p.str(key))
+	}
+	return n
+}
+
+// positiveFloat разбирает положительное число
+func (p *parser) positiveFloat(key string) float64 {
+	f, err := strconv.ParseFloat(p.str(key), 64)
+	if err != nil || f <= 0 {
+		p.fail(key, "must be a positive number, got %q", p.str(key))
+	}
+	return f
+}
+
+// bool разбирает логическое значение
+func (p *parser) bool(key string) bool {
+	b, err := strconv.ParseBool(p.str(key))
+	if err != nil {
+		p.fail(key, "must be a boolean, got %q", p.str(key))
+	}
+	return b
+}
+
+// oneOf возвращает значение настройки, допускающей только перечисленные значения
+func (p *parser) oneOf(key string, options ...string) string {
+	raw := strings.ToLower(p.str(key))
+	if !slices.Contains(options, raw) {
+		p.fail(key, "must be one of %s, got %q", strings.Join(options, ", "), raw)
+	}
+	return raw
+}
+
+// location загружает часовой пояс
+func (p *parser) location(key string) *time.Location {
+	loc, err := time.LoadLocation(p.str(key))
+	if err != nil {
+		p.fail(key, "unknown timezone %q", p.str(key))
+		return time.UTC
+	}
+	return loc
+}
+
+// ids разбирает список идентификаторов, перечисленных через запятую
+func (p *parser) ids(key string) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(p.str(key), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			p.fail(key, "invalid id %q", part)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+)
+
+// Константы для описания операций
+const (
+	opReloadConfig = "config.reload"
+)
+
+// Reloader хранит текущую конфигурацию и перечитывает её по запросу (например, по SIGHUP).
+// Новая конфигурация применяется целиком и только если она прошла проверку, после чего передаётся подписчикам.
+// Подписчики должны читать из неё только перезагружаемые настройки, остальные вступают в силу после перезапуска
+type Reloader struct {
+	log     *slog.Logger
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []func(*Config)
+}
+
+// NewReloader конструктор для Reloader
+func NewReloader(log *slog.Logger, cfg *Config) *Reloader {
+	r := &Reloader{log: log}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current возвращает текущую конфигурацию
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Subscribe добавляет подписчика, которому передаётся каждая успешно перезагруженная конфигурация
+func (r *Reloader) Subscribe(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload перечитывает конфигурацию из тех же источников. При ошибке проверки текущая конфигурация сохраняется.
+// Изменения записываются в лог, секреты скрываются
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev := r.current.Load()
+	next, err := LoadConfig(r.log, prev.args)
+	if err != nil {
+		r.log.Error("configuration reload failed, keeping current configuration", logger.Err(err), slog.String("operation", opReloadConfig))
+		return fmt.Errorf("%s: %w", opReloadConfig, err)
+	}
+
+	changed := 0
+	for _, s := range settings {
+		before, after := prev.values[s.env].raw, next.values[s.env].raw
+		if before == after {
+			continue
+		}
+		changed++
+		attrs := []any{
+			slog.String("key", s.env),
+			slog.String("old", s.display(before)),
+			slog.String("new", s.display(after)),
+			slog.String("source", next.values[s.env].source),
+			slog.String("operation", opReloadConfig),
+		}
+		if s.reloadable {
+			r.log.Info("configuration value changed", attrs...)
+		} else {
+			r.log.Warn("configuration value changed, restart required to apply", attrs...)
+		}
+	}
+	if changed == 0 {
+		r.log.Info("configuration reloaded, no changes", slog.String("operation", opReloadConfig))
+		return nil
+	}
+
+	r.current.Store(next)
+	for _, fn := range r.subscribers {
+		fn(next)
+	}
+	r.log.Info("configuration reloaded", slog.Int("changed", changed), slog.String("operation", opReloadConfig))
+	return nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+)
+
+const (
+	// secretRefPrefix префикс ссылки на секрет вида secret:<провайдер>:<ссылка>
+	secretRefPrefix = "secret:"
+	// fileSuffix суффикс переменной окружения с путём к файлу секрета (Docker/Kubernetes secrets)
+	fileSuffix = "_FILE"
+	// secretTimeout ограничение времени получения одного секрета
+	secretTimeout = 10 * time.Second
+)
+
+// SecretProvider описывает источник секретов, например файлы или менеджер секретов
+type SecretProvider interface {
+	// Secret возвращает значение секрета по ссылке ref. Ошибка не должна содержать значение секрета
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// secretProviders зарегистрированные источники секретов по именам
+var secretProviders = struct {
+	sync.RWMutex
+	byName map[string]SecretProvider
+}{byName: map[string]SecretProvider{"file": FileSecretProvider{}}}
+
+// RegisterSecretProvider регистрирует источник секретов name, должен вызываться до LoadConfig.
+// После регистрации значение секретной настройки вида secret:<name>:<ссылка> запрашивается у этого источника
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProviders.Lock()
+	defer secretProviders.Unlock()
+	secretProviders.byName[name] = provider
+}
+
+// FileSecretProvider источник секретов, хранящихся в локальных файлах, ссылка - путь к файлу
+type FileSecretProvider struct{}
+
+// Secret читает секрет из файла ref, завершающие переводы строк и пробелы отбрасываются
+func (FileSecretProvider) Secret(_ context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		// Текст ошибки os.ReadFile содержит только путь к файлу
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n\t "), nil
+}
+
+// resolveSecrets подставляет значения секретных настроек, заданных ссылкой secret:<провайдер>:<ссылка>
+func (v values) resolveSecrets() []error {
+	var errs []error
+	for _, s := range settings {
+		if !s.secret() {
+			continue
+		}
+		resolved, err := resolveSecret(s.env, v[s.env])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		v[s.env] = resolved
+	}
+	return errs
+}
+
+// resolveSecret возвращает значение секрета key, если оно задано ссылкой, иначе - значение без изменений
+func resolveSecret(key string, v value) (value, error) {
+	if !strings.HasPrefix(v.raw, secretRefPrefix) {
+		return v, nil
+	}
+	name, ref, ok := strings.Cut(strings.TrimPrefix(v.raw, secretRefPrefix), ":")
+	if !ok {
+		return v, fmt.Errorf("%s: secret reference must look like secret:<provider>:<ref>", key)
+	}
+
+	secretProviders.RLock()
+	provider, ok := secretProviders.byName[name]
+	secretProviders.RUnlock()
+	if !ok {
+		return v, fmt.Errorf("%s: unknown secret provider %q", key, name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	secret, err := provider.Secret(ctx, ref)
+	if err != nil {
+		return v, fmt.Errorf("%s: getting secret from %s provider: %w", key, name, err)
+	}
+	return value{raw: secret, source: v.source + "/secret:" + name}, nil
+}
+
+// LookupSecret возвращает секрет из переменной окружения key, файла из key_FILE или ссылки secret:<провайдер>:<ссылка>
+// и регистрирует его в логгере. Нужна утилитам, которым не требуется вся конфигурация бота
+func LookupSecret(key string) (string, error) {
+	v := value{raw: os.Getenv(key), source: sourceEnv}
+	if path, ok := os.LookupEnv(key + fileSuffix); ok {
+		if v.raw != "" {
+			return "", fmt.Errorf("%s: both %s and %s%s are set", key, key, key, fileSuffix)
+		}
+		v.raw = secretRefPrefix + "file:" + path
+	}
+
+	v, err := resolveSecret(key, v)
+	if err != nil {
+		return "", err
+	}
+	logger.RegisterSecret(values{key: v}.secretValuesOf(key)...)
+	return v.raw, nil
+}
+
+// secretValues возвращает значения секретных настроек, которые нужно вырезать из логов.
+// Для строки подключения к базе данных отдельно возвращается пароль
+func (v values) secretValues() []string {
+	var secrets []string
+	for _, s := range settings {
+		if s.secret() {
+			secrets = append(secrets, v.secretValuesOf(s.env)...)
+		}
+	}
+	return secrets
+}
+
+// secretValuesOf возвращает значение секрета key и, если это строка подключения, пароль из неё
+func (v values) secretValuesOf(key string) []string {
+	raw := v[key].raw
+	if raw == "" {
+		return nil
+	}
+	secrets := []string{raw}
+	if u, err := url.Parse(raw); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			secrets = append(secrets, password)
+		}
+	}
+	return secrets
+}
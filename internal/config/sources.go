@@ -0,0 +1,275 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Источники значений настроек, по возрастанию приоритета
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// setting описывает одну настройку микросервиса
+type setting struct {
+	// env имя переменной окружения, оно же ключ настройки
+	env string
+	// yaml путь к настройке в YAML-файле, уровни вложенности разделены точкой
+	yaml  string
+	def   string
+	usage string
+	// mask скрывает значение при выводе конфигурации, nil - значение не секретное
+	mask func(string) string
+	// reloadable настройку можно изменить без перезапуска, см. Reloader
+	reloadable bool
+}
+
+// secret настройка содержит секрет: её значение скрывается и может быть передано файлом или ссылкой на секрет
+func (s setting) secret() bool {
+	return s.mask != nil
+}
+
+// flag имя флага командной строки для настройки
+func (s setting) flag() string {
+	return strings.ToLower(strings.ReplaceAll(s.env, "_", "-"))
+}
+
+// settings все настройки микросервиса
+var settings = []setting{
+	{env: "TELEGRAM_BOT_TOKEN", yaml: "telegram.token", usage: "токен телеграм-бота", mask: maskSecret},
+	{env: "ADMIN_IDS", yaml: "telegram.admin_ids", usage: "Telegram ID администраторов через запятую", reloadable: true},
+	{env: "POLLER_TIMEOUT", yaml: "telegram.poller_timeout", def: "10s", usage: "таймаут long polling запросов к Telegram"},
+	{env: "PAGE_SIZE", yaml: "telegram.page_size", def: "5", usage: "количество событий на одной странице списка", reloadable: true},
+	{env: "WELCOME_MESSAGE", yaml: "telegram.welcome_message", def: `Привет! 👋\nЯ бот для отслеживания и регистрации на события.`, usage: "приветствие в ответ на /start, \\n - перенос строки", reloadable: true},
+	{env: "PROFILE_FLUSH_INTERVAL", yaml: "telegram.profile_flush_interval", def: "30s", usage: "период пакетного сохранения профилей пользователей"},
+	{env: "DEFAULT_TIMEZONE", yaml: "telegram.default_timezone", def: "Europe/Moscow", usage: "часовой пояс пользователей, не указавших свой"},
+	{env: "PAST_EVENTS_GRACE", yaml: "telegram.past_events_grace", def: "30m", usage: "сколько начавшееся событие ещё показывается в списке"},
+	{env: "UPDATE_WORKERS", yaml: "telegram.update_workers", def: "8", usage: "число обработчиков входящих обновлений"},
+	{env: "SHUTDOWN_TIMEOUT", yaml: "telegram.shutdown_timeout", def: "15s", usage: "время ожидания завершения обработчиков при остановке"},
+	{env: "DB_DRIVER_NAME", yaml: "database.driver", usage: "драйвер базы данных"},
+	{env: "DSN", yaml: "database.dsn", usage: "строка подключения к базе данных", mask: maskURLPassword},
+	{env: "AUTO_MIGRATE", yaml: "database.auto_migrate", def: "false", usage: "применять миграции базы данных при запуске бота"},
+	{env: "MIGRATION_TIMEOUT", yaml: "database.migration_timeout", def: "5m", usage: "ограничение времени ожидания блокировки и применения миграций"},
+	{env: "GRPC_ADDRESS", yaml: "grpc.address", usage: "адрес микросервиса событий"},
+	{env: "TICKET_SECRET", yaml: "tickets.secret", usage: "секрет подписи билетов, пустой - билеты отключены", mask: maskSecret},
+	{env: "CHECKIN_STAFF_IDS", yaml: "tickets.staff_ids", usage: "Telegram ID сотрудников, отмечающих посещение", reloadable: true},
+	{env: "FEEDBACK_EVENT_DURATION", yaml: "feedback.event_duration", def: "2h", usage: "предполагаемая длительность события"},
+	{env: "FEEDBACK_CHECK_INTERVAL", yaml: "feedback.check_interval", def: "10m", usage: "период проверки завершившихся событий", reloadable: true},
+	{env: "RECONCILE_INTERVAL", yaml: "registrations.reconcile_interval", def: "1h", usage: "период сверки регистраций с микросервисом событий", reloadable: true},
+	{env: "EVENT_CHANGES_INTERVAL", yaml: "registrations.event_changes_interval", def: "5m", usage: "период проверки изменений и отмен событий", reloadable: true},
+	{env: "OUTBOX_INTERVAL", yaml: "registrations.outbox_interval", def: "15s", usage: "период отправки отложенных заявок на регистрацию", reloadable: true},
+	{env: "OUTBOX_MAX_ATTEMPTS", yaml: "registrations.outbox_max_attempts", def: "20", usage: "число попыток отправки отложенной заявки на регистрацию"},
+	{env: "OUTBOX_RETRY_BACKOFF", yaml: "registrations.outbox_retry_backoff", def: "30s", usage: "пауза перед повторной отправкой заявки, удваивается с каждой попыткой"},
+	{env: "ANNOUNCE_INTERVAL", yaml: "announcements.interval", def: "10m", usage: "период проверки новых событий для анонсов подписчикам", reloadable: true},
+	{env: "BROADCAST_RATE", yaml: "announcements.broadcast_rate", def: "20", usage: "сообщений в секунду при рассылке анонсов и уведомлений", reloadable: true},
+	{env: "RATE_LIMIT_USER_RPS", yaml: "rate_limit.user_rps", def: "1", usage: "обновлений в секунду от одного пользователя", reloadable: true},
+	{env: "RATE_LIMIT_USER_BURST", yaml: "rate_limit.user_burst", def: "5", usage: "допустимый всплеск обновлений от одного пользователя", reloadable: true},
+	{env: "RATE_LIMIT_GLOBAL_RPS", yaml: "rate_limit.global_rps", def: "30", usage: "обновлений в секунду от всех пользователей", reloadable: true},
+	{env: "RATE_LIMIT_GLOBAL_BURST", yaml: "rate_limit.global_burst", def: "60", usage: "допустимый всплеск обновлений от всех пользователей", reloadable: true},
+	{env: "RATE_LIMIT_BAN_THRESHOLD", yaml: "rate_limit.ban_threshold", def: "20", usage: "превышений лимита за минуту до временного игнорирования, 0 - не игнорировать", reloadable: true},
+	{env: "RATE_LIMIT_BAN_DURATION", yaml: "rate_limit.ban_duration", def: "5m", usage: "время игнорирования флудящего пользователя", reloadable: true},
+	{env: "REQUEST_TIMEOUT", yaml: "timeouts.request", def: "15s", usage: "ограничение времени обработки обновления"},
+	{env: "PROFILE_FLUSH_TIMEOUT", yaml: "timeouts.profile_flush", def: "15s", usage: "ограничение времени сохранения профилей"},
+	{env: "JOB_TIMEOUT", yaml: "timeouts.job", def: "5m", usage: "ограничение времени одного запуска фоновой задачи"},
+	{env: "LOG_LEVEL", yaml: "log.level", def: "info", usage: "уровень логирования: debug, info, warn, error"},
+	{env: "LOG_FORMAT", yaml: "log.format", def: "json", usage: "формат логов: json, text"},
+	{env: "LOG_PII", yaml: "log.pii", def: "hash", usage: "персональные данные в логах: plain, hash, redact"},
+	{env: "LOG_PII_SALT", yaml: "log.pii_salt", usage: "соль для хеширования персональных данных", mask: maskSecret},
+	{env: "FEATURE_SEARCH", yaml: "features.search", def: "true", usage: "поиск событий по тексту сообщения", reloadable: true},
+	{env: "FEATURE_FEEDBACK_SURVEYS", yaml: "features.feedback_surveys", def: "true", usage: "опросы участников после событий"},
+	{env: "FEATURE_EVENT_CHANGE_NOTICES", yaml: "features.event_change_notices", def: "true", usage: "уведомления участников об изменении и отмене событий"},
+	{env: "FEATURE_REGISTRATION_OUTBOX", yaml: "features.registration_outbox", def: "true", usage: "принимать заявки на регистрацию, пока микросервис событий недоступен"},
+}
+
+// value итоговое значение настройки и его источник
+type value struct {
+	raw    string
+	source string
+}
+
+// values итоговые значения настроек по именам переменных окружения
+type values map[string]value
+
+// loadValues собирает значения настроек из всех источников. Возвращает также, запрошен ли вывод конфигурации,
+// и ошибки отдельных значений файла конфигурации, не мешающие прочитать остальные источники
+func loadValues(log *slog.Logger, args []string) (values, bool, []error, error) {
+	flags := flag.NewFlagSet("bot", flag.ContinueOnError)
+	configPath := flags.String("config", "", "путь к YAML-файлу конфигурации (по умолчанию CONFIG_FILE)")
+	printOnly := flags.Bool("print-config", false, "вывести итоговую конфигурацию со скрытыми секретами и завершиться")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.env] = flags.String(s.flag(), "", s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, false, nil, err
+	}
+
+	vals := make(values, len(settings))
+	for _, s := range settings {
+		vals[s.env] = value{raw: s.def, source: sourceDefault}
+	}
+
+	// Файл .env необязателен: в контейнере переменные окружения передаются напрямую
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil, fmt.Errorf("loading .env: %w", err)
+	}
+
+	path := *configPath
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	var fileErrs []error
+	if path != "" {
+		var err error
+		if fileErrs, err = vals.loadFile(path); err != nil {
+			return nil, false, nil, err
+		}
+		log.Info("configuration file loaded", slog.String("path", path))
+	}
+
+	for _, s := range settings {
+		raw, ok := os.LookupEnv(s.env)
+		if ok {
+			vals[s.env] = value{raw: raw, source: sourceEnv}
+		}
+		// Секрет можно передать файлом: X_FILE=/run/secrets/x вместо X=...
+		if path, fromFile := os.LookupEnv(s.env + fileSuffix); fromFile && s.secret() {
+			if raw != "" {
+				fileErrs = append(fileErrs, fmt.Errorf("%s: both %s and %s%s are set", s.env, s.env, s.env, fileSuffix))
+				continue
+			}
+			vals[s.env] = value{raw: secretRefPrefix + "file:" + path, source: sourceEnv}
+		}
+	}
+
+	byFlag := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byFlag[s.flag()] = s
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if s, ok := byFlag[f.Name]; ok {
+			vals[s.env] = value{raw: *flagValues[s.env], source: sourceFlag}
+		}
+	})
+
+	fileErrs = append(fileErrs, vals.resolveSecrets()...)
+
+	return vals, *printOnly, fileErrs, nil
+}
+
+// loadFile читает значения настроек из YAML-файла. Неизвестные ключи возвращаются как ошибки значений,
+// чтобы опечатки не оставались незамеченными
+func (v values) loadFile(path string) ([]error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var tree map[string]any
+	if err = yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	flat := make(map[string]string)
+	flatten("", tree, flat)
+
+	byYAML := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byYAML[s.yaml] = s
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		raw := flat[key]
+		s, ok := byYAML[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q", path, key))
+			continue
+		}
+		v[s.env] = value{raw: raw, source: sourceFile}
+	}
+	return errs, nil
+}
+
+// flatten разворачивает вложенные YAML-узлы в ключи через точку, списки объединяются через запятую
+func flatten(prefix string, node any, out map[string]string) {
+	switch n := node.(type) {
+	case map[string]any:
+		for k, child := range n {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flatten(key, child, out)
+		}
+	case []any:
+		parts := make([]string, 0, len(n))
+		for _, item := range n {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		out[prefix] = strings.Join(parts, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(n)
+	}
+}
+
+// print выводит значения настроек в порядке их объявления
+func (v values) print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range settings {
+		val := v[s.env]
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.env, s.display(val.raw), val.source)
+	}
+	return tw.Flush()
+}
+
+// display возвращает значение настройки для вывода, секреты скрываются
+func (s setting) display(raw string) string {
+	if s.mask != nil {
+		return s.mask(raw)
+	}
+	return raw
+}
+
+// maskSecret скрывает непустое значение целиком
+func maskSecret(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	return "******"
+}
+
+// maskURLPassword скрывает пароль в строке подключения
+func maskURLPassword(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return maskSecret(raw)
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxxx")
+	}
+	return u.String()
+}
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/reqctx"
+)
+
+// Форматы вывода логов
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Режимы обработки персональных данных в логах
+const (
+	// PIIPlain персональные данные пишутся как есть
+	PIIPlain = "plain"
+	// PIIHash персональные данные заменяются хешем, по которому записи одного пользователя можно сопоставить
+	PIIHash = "hash"
+	// PIIRedact персональные данные полностью скрываются
+	PIIRedact = "redact"
+)
+
+// ErrorKey единый ключ атрибута ошибки во всех слоях
+const ErrorKey = "error"
+
+// redactedValue значение, которым заменяются скрытые персональные данные
+const redactedValue = "[redacted]"
+
+// piiKeys ключи атрибутов, содержащие персональные данные
+var piiKeys = map[string]struct{}{
+	"chat_id":       {},
+	"user_id":       {},
+	"staff_chat_id": {},
+	"username":      {},
+	"first_name":    {},
+	"last_name":     {},
+}
+
+// Err возвращает атрибут ошибки с единым ключом
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String(ErrorKey, "")
+	}
+	return slog.String(ErrorKey, err.Error())
+}
+
+// New создаёт логгер с заданными уровнем ("debug", "info", "warn", "error"), форматом и режимом обработки
+// персональных данных. salt добавляется к персональным данным перед хешированием.
+// Зарегистрированные через RegisterSecret секреты вырезаются из всех записей
+func New(w io.Writer, level, format, pii, salt string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	replace, err := piiReplacer(pii, salt)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		a = scrubAttr(a)
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		return a
+	}}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+
+	return slog.New(&contextHandler{Handler: h}), nil
+}
+
+// piiReplacer возвращает функцию для slog.HandlerOptions.ReplaceAttr, обрабатывающую персональные данные
+func piiReplacer(mode, salt string) (func([]string, slog.Attr) slog.Attr, error) {
+	switch mode {
+	case PIIPlain:
+		return nil, nil
+	case PIIRedact:
+		return func(_ []string, a slog.Attr) slog.Attr {
+			if _, ok := piiKeys[a.Key]; ok {
+				return slog.String(a.Key, redactedValue)
+			}
+			return a
+		}, nil
+	case PIIHash:
+		return func(_ []string, a slog.Attr) slog.Attr {
+			if _, ok := piiKeys[a.Key]; ok {
+				sum := sha256.Sum256([]byte(salt + a.Value.String()))
+				return slog.String(a.Key, hex.EncodeToString(sum[:6]))
+			}
+			return a
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid log pii mode %q", mode)
+}
+
+// contextHandler добавляет к записи значения запроса из контекста: идентификатор обновления, чат, трассировку и операцию.
+// Атрибуты, уже указанные в записи явно, не дублируются
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle реализует slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	req, ok := reqctx.FromContext(ctx)
+	if !ok {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	present := make(map[string]struct{}, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		present[a.Key] = struct{}{}
+		return true
+	})
+
+	add := func(a slog.Attr) {
+		if _, ok := present[a.Key]; !ok {
+			r.AddAttrs(a)
+		}
+	}
+	add(slog.Int("update_id", req.UpdateID))
+	add(slog.String("trace_id", req.TraceID))
+	if req.ChatID != 0 {
+		add(slog.Int64("chat_id", req.ChatID))
+	}
+	if req.Operation != "" {
+		add(slog.String("operation", req.Operation))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs реализует slog.Handler
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup реализует slog.Handler
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
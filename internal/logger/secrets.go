@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// secretValue значение, которым заменяются секреты в записях лога
+const secretValue = "******"
+
+// minSecretLen секреты короче этой длины не вырезаются, чтобы не портить записи совпадениями
+const minSecretLen = 4
+
+// secrets значения секретов, которые не должны попадать в логи
+var secrets struct {
+	sync.RWMutex
+	values []string
+}
+
+// RegisterSecret запоминает значение секрета: оно вырезается из сообщений и атрибутов всех логгеров пакета,
+// в том числе из текстов ошибок сторонних библиотек, например из URL запросов с токеном бота
+func RegisterSecret(values ...string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+	for _, v := range values {
+		if len(v) >= minSecretLen {
+			secrets.values = append(secrets.values, v)
+		}
+	}
+}
+
+// Scrub заменяет в строке s все зарегистрированные секреты
+func Scrub(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+	for _, v := range secrets.values {
+		s = strings.ReplaceAll(s, v, secretValue)
+	}
+	return s
+}
+
+// scrubAttr вырезает секреты из строкового представления атрибута
+func scrubAttr(a slog.Attr) slog.Attr {
+	kind := a.Value.Kind()
+	if kind != slog.KindString && kind != slog.KindAny {
+		return a
+	}
+	s := a.Value.String()
+	if scrubbed := Scrub(s); scrubbed != s {
+		return slog.String(a.Key, scrubbed)
+	}
+	return a
+}
+
+// scrubbedError ошибка с вырезанными из текста секретами
+type scrubbedError struct {
+	msg string
+	err error
+}
+
+// Error реализует error
+func (e *scrubbedError) Error() string {
+	return e.msg
+}
+
+// Unwrap сохраняет цепочку errors.Is/errors.As исходной ошибки
+func (e *scrubbedError) Unwrap() error {
+	return e.err
+}
+
+// ScrubError возвращает ошибку, в тексте которой вырезаны зарегистрированные секреты
+func ScrubError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := Scrub(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &scrubbedError{msg: msg, err: err}
+}
@@ -0,0 +1,16 @@
+package models
+
+// AuditAction описывает тип запроса пользователя, связанного с его персональными данными
+type AuditAction string
+
+// Возможные типы запросов
+const (
+	// AuditActionExport пользователь запросил выгрузку своих данных
+	AuditActionExport AuditAction = "export"
+	// AuditActionDeleteRequested пользователь запросил удаление своих данных
+	AuditActionDeleteRequested AuditAction = "delete_requested"
+	// AuditActionDeleteCancelled пользователь отменил удаление своих данных
+	AuditActionDeleteCancelled AuditAction = "delete_cancelled"
+	// AuditActionDeleteCompleted данные пользователя удалены
+	AuditActionDeleteCompleted AuditAction = "delete_completed"
+)
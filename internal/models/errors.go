@@ -0,0 +1,35 @@
+package models
+
+import "errors"
+
+// Общие ошибки, которыми обмениваются слои микросервиса
+var (
+	// ErrUserNotFound пользователь отсутствует в базе данных
+	ErrUserNotFound = errors.New("user not found")
+	// ErrNotSupported операция не поддерживается микросервисом событий
+	ErrNotSupported = errors.New("operation is not supported by event service")
+	// ErrAlreadyRegistered пользователь уже зарегистрирован на событие
+	ErrAlreadyRegistered = errors.New("user is already registered")
+	// ErrEventServiceUnavailable микросервис событий временно недоступен, запрос можно повторить позже
+	ErrEventServiceUnavailable = errors.New("event service is unavailable")
+	// ErrRequestRejected микросервис событий отклонил запрос, повтор без изменений даст тот же результат
+	ErrRequestRejected = errors.New("request rejected by event service")
+	// ErrUnknownColumn запрошена колонка, недоступная для выгрузки списка участников
+	ErrUnknownColumn = errors.New("unknown participant column")
+	// ErrUnknownEventField неизвестное поле дополнительной информации о событии
+	ErrUnknownEventField = errors.New("unknown event field")
+	// ErrInvalidLocation координаты события не в формате "широта,долгота" или вне допустимых пределов
+	ErrInvalidLocation = errors.New("invalid event location")
+	// ErrInvalidURL ссылка не является адресом http или https
+	ErrInvalidURL = errors.New("invalid url")
+	// ErrInvalidFilter неизвестный или некорректный код фильтра событий по дате
+	ErrInvalidFilter = errors.New("invalid date filter")
+	// ErrInvalidKeywords ключевые слова подписки не прошли проверку
+	ErrInvalidKeywords = errors.New("invalid subscription keywords")
+	// ErrTicketsDisabled секрет для подписи билетов не задан
+	ErrTicketsDisabled = errors.New("tickets are disabled")
+	// ErrRegistrationNotFound у пользователя нет активной регистрации на событие
+	ErrRegistrationNotFound = errors.New("registration not found")
+	// ErrTicketRevoked билет заменён выданным позже
+	ErrTicketRevoked = errors.New("ticket has been reissued")
+)
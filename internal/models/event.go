@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// EventExtras описывает дополнительную информацию о событии, которой нет в контракте микросервиса событий:
+// обложку, место проведения и внешнюю ссылку. Задаётся администраторами бота
+type EventExtras struct {
+	EventID string
+	// Photo ссылка на изображение или file_id, загруженный в Telegram
+	Photo   string
+	Address string
+	// Latitude и Longitude координаты места проведения, nil - не заданы
+	Latitude  *float64
+	Longitude *float64
+	URL       string
+}
+
+// HasLocation проверяет, заданы ли координаты места проведения
+func (e EventExtras) HasLocation() bool {
+	return e.Latitude != nil && e.Longitude != nil
+}
+
+// EventStatus описывает состояние события, известное боту
+type EventStatus string
+
+// Возможные состояния события
+const (
+	// EventStatusScheduled событие есть в каталоге микросервиса событий
+	EventStatusScheduled EventStatus = "scheduled"
+	// EventStatusCancelled событие пропало из каталога до начала
+	EventStatusCancelled EventStatus = "cancelled"
+)
+
+// EventSnapshot описывает сохранённое состояние события, с которым сравнивается каталог микросервиса событий
+type EventSnapshot struct {
+	EventID string `json:"event_id"`
+	Title   string `json:"title"`
+	// StartsAt время начала, нулевое - не задано
+	StartsAt time.Time   `json:"starts_at"`
+	Status   EventStatus `json:"status"`
+}
+
+// EventChangeKind описывает вид изменения события
+type EventChangeKind string
+
+// Возможные виды изменений
+const (
+	// EventChangeUpdated изменились название или время начала
+	EventChangeUpdated EventChangeKind = "updated"
+	// EventChangeCancelled событие отменено
+	EventChangeCancelled EventChangeKind = "cancelled"
+	// EventChangeRestored отменённое событие снова появилось в каталоге
+	EventChangeRestored EventChangeKind = "restored"
+)
+
+// EventChange описывает изменение события между двумя опросами каталога
+type EventChange struct {
+	Kind EventChangeKind `json:"kind"`
+	Old  EventSnapshot   `json:"old"`
+	New  EventSnapshot   `json:"new"`
+}
+
+// TitleChanged проверяет, изменилось ли название события
+func (c EventChange) TitleChanged() bool {
+	return c.Old.Title != c.New.Title
+}
+
+// TimeChanged проверяет, изменилось ли время начала события
+func (c EventChange) TimeChanged() bool {
+	return !c.Old.StartsAt.Equal(c.New.StartsAt)
+}
@@ -0,0 +1,18 @@
+package models
+
+// FeedbackSurvey описывает опрос участников завершившегося события
+type FeedbackSurvey struct {
+	EventID    string `json:"event_id"`
+	EventTitle string `json:"event_title"`
+}
+
+// FeedbackSummary описывает агрегированные отзывы о событии
+type FeedbackSummary struct {
+	EventID string
+	Count   int
+	Average float64
+	// Ratings количество оценок от 1 до 5, индекс 0 соответствует оценке 1
+	Ratings [5]int
+	// Comments последние оставленные комментарии
+	Comments []string
+}
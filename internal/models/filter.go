@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Коды фильтров событий по дате
+const (
+	FilterAll     = "all"
+	FilterToday   = "today"
+	FilterWeek    = "week"
+	FilterWeekend = "weekend"
+	FilterMonth   = "month"
+	// FilterCustomPrefix префикс произвольного диапазона вида c20260101-20260131
+	FilterCustomPrefix = "c"
+	// FilterCustomLayout формат дат в коде произвольного диапазона
+	FilterCustomLayout = "20060102"
+)
+
+// CustomFilter возвращает код фильтра для произвольного диапазона дат включительно
+func CustomFilter(from, to time.Time) string {
+	if to.Before(from) {
+		from, to = to, from
+	}
+	return FilterCustomPrefix + from.Format(FilterCustomLayout) + "-" + to.Format(FilterCustomLayout)
+}
@@ -0,0 +1,42 @@
+package models
+
+// NotificationKind описывает вид уведомления из очереди рассылок
+type NotificationKind string
+
+// Возможные виды уведомлений
+const (
+	// NotificationEventChange уведомление зарегистрированного пользователя об изменении или отмене события
+	NotificationEventChange NotificationKind = "event_change"
+	// NotificationEventAnnouncement анонс нового события подписчику
+	NotificationEventAnnouncement NotificationKind = "event_announcement"
+	// NotificationFeedbackSurvey просьба оценить завершившееся событие
+	NotificationFeedbackSurvey NotificationKind = "feedback_survey"
+)
+
+// NotificationStatus описывает состояние уведомления в очереди рассылок
+type NotificationStatus string
+
+// Возможные состояния уведомления
+const (
+	// NotificationStatusPending уведомление ожидает отправки
+	NotificationStatusPending NotificationStatus = "pending"
+	// NotificationStatusSent уведомление доставлено пользователю
+	NotificationStatusSent NotificationStatus = "sent"
+	// NotificationStatusFailed уведомление не удалось доставить
+	NotificationStatusFailed NotificationStatus = "failed"
+)
+
+// Notification описывает уведомление одному получателю из очереди рассылок
+type Notification struct {
+	ID     int64
+	ChatID int64
+	Kind   NotificationKind
+	// Change изменение события, заполняется для NotificationEventChange
+	Change *EventChange
+	// Announcement анонс нового события, заполняется для NotificationEventAnnouncement
+	Announcement *EventAnnouncement
+	// Survey опрос о событии, заполняется для NotificationFeedbackSurvey
+	Survey *FeedbackSurvey
+	// Attempts количество попыток отправки, включая текущую
+	Attempts int
+}
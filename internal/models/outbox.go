@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// RegistrationRequest описывает запрос пользователя на регистрацию на событие
+type RegistrationRequest struct {
+	EventID  string
+	ChatID   int64
+	Username string
+	// IdempotencyKey ключ, по которому микросервис событий отличает повтор запроса от новой регистрации
+	IdempotencyKey string
+	// MessageID сообщение с карточкой события, в котором показывается результат регистрации, 0 - нет сообщения
+	MessageID int
+}
+
+// RegistrationOutcome описывает результат запроса на регистрацию
+type RegistrationOutcome string
+
+// Возможные результаты регистрации
+const (
+	// RegistrationConfirmed регистрация подтверждена микросервисом событий
+	RegistrationConfirmed RegistrationOutcome = "confirmed"
+	// RegistrationAlreadyExists пользователь уже был зарегистрирован на событие
+	RegistrationAlreadyExists RegistrationOutcome = "already_exists"
+	// RegistrationRejected микросервис событий отказал в регистрации
+	RegistrationRejected RegistrationOutcome = "rejected"
+	// RegistrationQueued микросервис событий недоступен, заявка сохранена в очередь и будет отправлена позже
+	RegistrationQueued RegistrationOutcome = "queued"
+	// RegistrationAlreadyQueued заявка из того же сообщения уже ожидает отправки
+	RegistrationAlreadyQueued RegistrationOutcome = "already_queued"
+	// RegistrationFailed заявку из очереди не удалось доставить за отведённое число попыток
+	RegistrationFailed RegistrationOutcome = "failed"
+)
+
+// OutboxStatus описывает состояние заявки в очереди на регистрацию
+type OutboxStatus string
+
+// Возможные состояния заявки
+const (
+	// OutboxStatusPending заявка ожидает отправки
+	OutboxStatusPending OutboxStatus = "pending"
+	// OutboxStatusDelivered заявка обработана микросервисом событий
+	OutboxStatusDelivered OutboxStatus = "delivered"
+	// OutboxStatusFailed заявку не удалось доставить
+	OutboxStatusFailed OutboxStatus = "failed"
+)
+
+// OutboxEntry описывает заявку на регистрацию, ожидающую отправки в микросервис событий
+type OutboxEntry struct {
+	ID      int64
+	Request RegistrationRequest
+	// Attempts количество попыток отправки, включая текущую
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// RegistrationDelivery описывает итог обработки заявки из очереди, о котором нужно сообщить пользователю
+type RegistrationDelivery struct {
+	Request RegistrationRequest
+	Outcome RegistrationOutcome
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Registrant описывает участника, зарегистрированного на событие
+type Registrant struct {
+	ChatID       int64
+	Username     string
+	RegisteredAt time.Time
+}
+
+// ParticipantColumns колонки, доступные для выгрузки списка участников, в порядке по умолчанию
+var ParticipantColumns = []string{"chat_id", "username", "first_name", "last_name", "language_code", "registered_at"}
+
+// CheckIn описывает отметку о посещении события участником
+type CheckIn struct {
+	EventID     string
+	ChatID      int64
+	Nonce       string
+	Username    string
+	CheckedInBy int64
+	CheckedInAt time.Time
+	// Duplicate признак того, что участник уже был отмечен ранее
+	Duplicate bool
+}
+
+// RegistrationStatus описывает состояние локальной копии регистрации
+type RegistrationStatus string
+
+// Возможные состояния регистрации
+const (
+	// RegistrationStatusActive регистрация подтверждена микросервисом событий
+	RegistrationStatusActive RegistrationStatus = "active"
+	// RegistrationStatusMissing регистрация не найдена в микросервисе событий при сверке
+	RegistrationStatusMissing RegistrationStatus = "missing"
+)
+
+// RegistrationSource описывает, откуда в базе данных бота появилась регистрация
+type RegistrationSource string
+
+// Возможные источники регистрации
+const (
+	// RegistrationSourceBot пользователь зарегистрировался через бота
+	RegistrationSourceBot RegistrationSource = "bot"
+	// RegistrationSourceReconcile регистрация найдена в микросервисе событий при сверке
+	RegistrationSourceReconcile RegistrationSource = "reconcile"
+)
+
+// Registration описывает локальную копию регистрации пользователя на событие
+type Registration struct {
+	ChatID    int64              `json:"chat_id"`
+	EventID   string             `json:"event_id"`
+	Status    RegistrationStatus `json:"status"`
+	Source    RegistrationSource `json:"source"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// RegistrationDrift описывает расхождения, найденные при сверке регистраций с микросервисом событий
+type RegistrationDrift struct {
+	// Added регистрации, которые есть только в микросервисе событий
+	Added int
+	// Missing регистрации, которые есть только в базе данных бота
+	Missing int
+	// Restored регистрации, снова найденные в микросервисе событий
+	Restored int
+	// Orphaned регистрации на события, которых больше нет в микросервисе событий
+	Orphaned int
+	// Absent регистрации на события, которых нет в каталоге, но ещё недостаточно сверок подряд,
+	// чтобы считать их исчезнувшими. В Total не входят
+	Absent int
+	// RegistrantsChecked списки участников удалось сравнить, а не только наличие событий
+	RegistrantsChecked bool
+	// CatalogIncomplete каталог событий пуст или резко сократился, поэтому исчезнувшие события не искались
+	CatalogIncomplete bool
+}
+
+// Total возвращает общее количество расхождений
+func (d RegistrationDrift) Total() int {
+	return d.Added + d.Missing + d.Restored + d.Orphaned
+}
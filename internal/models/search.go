@@ -0,0 +1,11 @@
+package models
+
+import pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+
+// SearchResult описывает результат поиска событий
+type SearchResult struct {
+	// Events найденные события, отсортированные по релевантности
+	Events []*pb.Event
+	// Suggestions похожие события, если по запросу ничего не найдено
+	Suggestions []*pb.Event
+}
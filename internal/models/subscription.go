@@ -0,0 +1,39 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Subscription описывает подписку пользователя на анонсы новых событий
+type Subscription struct {
+	ChatID int64
+	Active bool
+	// Keywords ключевые слова в нижнем регистре, пустой список - интересны все события
+	Keywords []string
+}
+
+// Matches проверяет, подходит ли событие с названием и описанием text под ключевые слова подписки
+func (s Subscription) Matches(text string) bool {
+	if len(s.Keywords) == 0 {
+		return true
+	}
+	text = strings.ToLower(text)
+	for _, k := range s.Keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// EventAnnouncement описывает анонс нового события для подписанных пользователей
+type EventAnnouncement struct {
+	EventID     string `json:"event_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// StartsAt время начала, нулевое - не задано
+	StartsAt time.Time `json:"starts_at"`
+	// ChatIDs подписчики, которым подходит событие; в очереди рассылок у каждого получателя своё уведомление
+	ChatIDs []int64 `json:"-"`
+}
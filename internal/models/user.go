@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// UserStatus описывает статус пользователя по отношению к боту
+type UserStatus string
+
+// Возможные статусы пользователя
+const (
+	// UserStatusActive пользователь взаимодействует с ботом, сообщения доставляются
+	UserStatusActive UserStatus = "active"
+	// UserStatusBlocked пользователь заблокировал бота
+	UserStatusBlocked UserStatus = "blocked"
+	// UserStatusDeactivated аккаунт пользователя удалён в Telegram
+	UserStatusDeactivated UserStatus = "deactivated"
+)
+
+// IsValid проверяет, что статус входит в список допустимых значений
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case UserStatusActive, UserStatusBlocked, UserStatusDeactivated:
+		return true
+	}
+	return false
+}
+
+// UserProfile описывает профиль пользователя Telegram, сохраняемый в базе данных
+type UserProfile struct {
+	ChatID       int64
+	Username     string
+	FirstName    string
+	LastName     string
+	LanguageCode string
+}
+
+// User описывает сохранённые о пользователе данные
+type User struct {
+	ChatID       int64      `json:"chat_id"`
+	Username     string     `json:"username"`
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	LanguageCode string     `json:"language_code"`
+	Timezone     string     `json:"timezone"`
+	Status       UserStatus `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+	// Subscribed и SubscriptionKeywords подписка на анонсы новых событий (/subscribe)
+	Subscribed           bool     `json:"subscribed"`
+	SubscriptionKeywords []string `json:"subscription_keywords"`
+}
+
+// UserDataExport описывает выгрузку персональных данных пользователя по запросу /mydata
+type UserDataExport struct {
+	User          *User          `json:"user"`
+	Registrations []Registration `json:"registrations"`
+	CheckIns      []UserCheckIn  `json:"checkins"`
+	Feedback      []UserFeedback `json:"feedback"`
+	ExportedAt    time.Time      `json:"exported_at"`
+}
+
+// UserCheckIn описывает отметку о посещении события в выгрузке данных пользователя
+type UserCheckIn struct {
+	EventID     string    `json:"event_id"`
+	CheckedInAt time.Time `json:"checked_in_at"`
+}
+
+// UserFeedback описывает отзыв о событии в выгрузке данных пользователя
+type UserFeedback struct {
+	EventID   string    `json:"event_id"`
+	Rating    int       `json:"rating"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
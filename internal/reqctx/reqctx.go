@@ -0,0 +1,44 @@
+package reqctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// ctxKey ключ, под которым значения запроса хранятся в контексте
+type ctxKey struct{}
+
+// Request описывает значения, относящиеся к обработке одного обновления, нужны для сквозного логирования
+type Request struct {
+	UpdateID int
+	ChatID   int64
+	TraceID  string
+	// Operation обрабатываемая команда или действие callback'а
+	Operation string
+}
+
+// WithRequest возвращает копию контекста со значениями запроса
+func WithRequest(ctx context.Context, r Request) context.Context {
+	return context.WithValue(ctx, ctxKey{}, r)
+}
+
+// FromContext возвращает значения запроса, сохранённые в контексте
+func FromContext(ctx context.Context) (Request, bool) {
+	r, ok := ctx.Value(ctxKey{}).(Request)
+	return r, ok
+}
+
+// WithOperation возвращает копию контекста, в значениях запроса которого указана операция op
+func WithOperation(ctx context.Context, op string) context.Context {
+	r, _ := FromContext(ctx)
+	r.Operation = op
+	return WithRequest(ctx, r)
+}
+
+// NewTraceID генерирует случайный идентификатор трассировки
+func NewTraceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
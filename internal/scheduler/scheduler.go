@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job описывает периодическую фоновую задачу
+type Job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+	// reset передаёт запущенной задаче новый период выполнения
+	reset chan time.Duration
+}
+
+// Scheduler запускает фоновые задачи микросервиса с заданной периодичностью
+type Scheduler struct {
+	log     *slog.Logger
+	timeout time.Duration
+	jobs    []Job
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+}
+
+// NewScheduler конструктор для Scheduler, timeout ограничивает время одного запуска задачи
+func NewScheduler(log *slog.Logger, timeout time.Duration) *Scheduler {
+	return &Scheduler{log: log, timeout: timeout}
+}
+
+// Add добавляет задачу, должен вызываться до Start
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context)) {
+	s.jobs = append(s.jobs, Job{name: name, interval: interval, run: run, reset: make(chan time.Duration, 1)})
+}
+
+// SetInterval меняет период выполнения задачи name, в том числе уже запущенной
+func (s *Scheduler) SetInterval(name string, interval time.Duration) {
+	for i := range s.jobs {
+		job := &s.jobs[i]
+		if job.name != name || job.interval == interval {
+			continue
+		}
+		job.interval = interval
+		// В канале хранится только последний период: устаревшее значение, если его ещё не прочитали, отбрасывается
+		select {
+		case <-job.reset:
+		default:
+		}
+		job.reset <- interval
+		s.log.Info("job interval changed", slog.String("job", name), slog.Duration("interval", interval))
+	}
+}
+
+// Start запускает все задачи, каждая выполняется в отдельной горутине, пока не отменён ctx или не вызван Stop
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+	s.log.Info("scheduler started", slog.Int("jobs", len(s.jobs)))
+}
+
+// Stop отменяет контекст задач и дожидается их завершения
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.log.Info("scheduler stopped")
+}
+
+// loop выполняет задачу с заданной периодичностью, пока не будет отменён контекст
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, job)
+		case interval := <-job.reset:
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// runOnce выполняет задачу, отлавливая панику, чтобы она не остановила планировщик
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Error("panic recovered in scheduled job", slog.String("job", job.name), slog.Any("panic", r))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	started := time.Now()
+	job.run(ctx)
+	s.log.Debug("scheduled job finished", slog.String("job", job.name), slog.Duration("took", time.Since(started)))
+}
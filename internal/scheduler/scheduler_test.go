@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestScheduler создаёт планировщик без вывода логов
+func newTestScheduler() *Scheduler {
+	return NewScheduler(slog.New(slog.NewTextHandler(io.Discard, nil)), time.Second)
+}
+
+// waitRuns ждёт, пока задача выполнится не меньше want раз
+func waitRuns(t *testing.T, runs *atomic.Int32, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runs.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("job ran %d times, want at least %d", runs.Load(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerSetInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		// beforeStart период меняется до запуска планировщика
+		beforeStart bool
+	}{
+		{name: "running job", beforeStart: false},
+		{name: "job not started yet", beforeStart: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScheduler()
+			var runs atomic.Int32
+			s.Add("job", time.Hour, func(context.Context) { runs.Add(1) })
+
+			if tt.beforeStart {
+				s.SetInterval("job", 5*time.Millisecond)
+			}
+			s.Start(context.Background())
+			defer s.Stop()
+			if !tt.beforeStart {
+				s.SetInterval("job", 5*time.Millisecond)
+			}
+
+			waitRuns(t, &runs, 3)
+		})
+	}
+}
+
+func TestSchedulerSetIntervalKeepsLatestValue(t *testing.T) {
+	s := newTestScheduler()
+	s.Add("job", time.Hour, func(context.Context) {})
+
+	// Несколько перезагрузок конфигурации подряд, пока задача не успела прочитать период
+	s.SetInterval("job", time.Minute)
+	s.SetInterval("job", 2*time.Minute)
+	s.SetInterval("job", time.Hour)
+	s.SetInterval("unknown", time.Second)
+
+	select {
+	case got := <-s.jobs[0].reset:
+		if got != time.Hour {
+			t.Errorf("got interval %v, want %v", got, time.Hour)
+		}
+	default:
+		t.Fatal("no interval passed to the job")
+	}
+	if s.jobs[0].interval != time.Hour {
+		t.Errorf("job interval = %v, want %v", s.jobs[0].interval, time.Hour)
+	}
+}
+
+func TestSchedulerRecoversFromPanic(t *testing.T) {
+	s := newTestScheduler()
+	var runs atomic.Int32
+	s.Add("job", 5*time.Millisecond, func(context.Context) {
+		runs.Add(1)
+		panic("boom")
+	})
+
+	s.Start(context.Background())
+	defer s.Stop()
+	waitRuns(t, &runs, 2)
+}
+
+func TestSchedulerStopWithoutStart(t *testing.T) {
+	s := newTestScheduler()
+	s.Add("job", time.Hour, func(context.Context) {})
+	s.Stop()
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/ticket"
+)
+
+// Константы для описания операций
+const (
+	opIssueTicket = "service.IssueTicket"
+	opCheckIn     = "service.CheckIn"
+)
+
+// CheckInSaver описывает метод для отметки посещения события
+type CheckInSaver interface {
+	SaveCheckIn(ctx context.Context, checkIn models.CheckIn) (models.CheckIn, error)
+}
+
+// TicketSigner описывает методы для выпуска и проверки подписанных билетов
+type TicketSigner interface {
+	Issue(eventID string, chatID int64) (ticket.Ticket, string, error)
+	Verify(code string) (ticket.Ticket, error)
+}
+
+// IssueTicket выпускает подписанный билет участника события и возвращает его код.
+// Nonce билета сохраняется в активной регистрации, поэтому выданный ранее билет перестаёт приниматься
+func (s *Service) IssueTicket(ctx context.Context, eventID string, chatID int64) (string, error) {
+	if s.tickets == nil {
+		return "", models.ErrTicketsDisabled
+	}
+
+	if err := validateEventID(eventID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opIssueTicket))
+		return "", err
+	}
+
+	if err := validateChatID(chatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opIssueTicket))
+		return "", err
+	}
+
+	t, code, err := s.tickets.Issue(eventID, chatID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", opIssueTicket, err)
+	}
+
+	if err = s.registrations.SetTicketNonce(ctx, chatID, eventID, t.Nonce); err != nil {
+		return "", fmt.Errorf("%s: %w", opIssueTicket, err)
+	}
+	return code, nil
+}
+
+// CheckIn проверяет подпись билета, наличие активной регистрации на событие и то, что билет не заменён выданным позже,
+// и отмечает посещение события участником. Регистрации на события, пропавшие из каталога, сверка помечает
+// отсутствующими, поэтому активная регистрация означает и то, что событие существует
+func (s *Service) CheckIn(ctx context.Context, code string, staffChatID int64) (models.CheckIn, error) {
+	if s.tickets == nil {
+		return models.CheckIn{}, models.ErrTicketsDisabled
+	}
+
+	t, err := s.tickets.Verify(code)
+	if err != nil {
+		s.log.WarnContext(ctx, "invalid ticket", slog.Int64("staff_chat_id", staffChatID), logger.Err(err), slog.String("operation", opCheckIn))
+		return models.CheckIn{}, err
+	}
+
+	nonce, err := s.registrations.GetTicketNonce(ctx, t.ChatID, t.EventID)
+	if err != nil {
+		if errors.Is(err, models.ErrRegistrationNotFound) {
+			s.log.WarnContext(ctx, "ticket without active registration", slog.String("event_id", t.EventID), slog.Int64("chat_id", t.ChatID), slog.String("operation", opCheckIn))
+		}
+		return models.CheckIn{}, fmt.Errorf("%s: %w", opCheckIn, err)
+	}
+	// Пустой nonce у билетов, выданных до того, как nonce начал сохраняться; они проверяются только по подписи
+	if nonce != "" && nonce != t.Nonce {
+		s.log.WarnContext(ctx, "reissued ticket", slog.String("event_id", t.EventID), slog.Int64("chat_id", t.ChatID), slog.String("operation", opCheckIn))
+		return models.CheckIn{}, models.ErrTicketRevoked
+	}
+
+	checkIn, err := s.checkIns.SaveCheckIn(ctx, models.CheckIn{
+		EventID:     t.EventID,
+		ChatID:      t.ChatID,
+		Nonce:       t.Nonce,
+		CheckedInBy: staffChatID,
+		CheckedInAt: time.Now(),
+	})
+	if err != nil {
+		return models.CheckIn{}, fmt.Errorf("%s: %w", opCheckIn, err)
+	}
+
+	if user, err := s.userData.GetUser(ctx, t.ChatID); err == nil {
+		checkIn.Username = user.Username
+	}
+
+	s.log.InfoContext(ctx, "participant checked in", slog.String("event_id", checkIn.EventID), slog.Int64("chat_id", checkIn.ChatID), slog.Bool("duplicate", checkIn.Duplicate), slog.String("operation", opCheckIn))
+	return checkIn, nil
+}
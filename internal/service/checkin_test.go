@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/ticket"
+)
+
+// fakeRegistrations хранилище регистраций для тестов, неиспользуемые методы не реализованы
+type fakeRegistrations struct {
+	RegistrationKeeper
+	nonces map[registrationKey]string
+}
+
+func (f *fakeRegistrations) SetTicketNonce(_ context.Context, chatID int64, eventID, nonce string) error {
+	key := registrationKey{chatID: chatID, eventID: eventID}
+	if _, ok := f.nonces[key]; !ok {
+		return models.ErrRegistrationNotFound
+	}
+	f.nonces[key] = nonce
+	return nil
+}
+
+func (f *fakeRegistrations) GetTicketNonce(_ context.Context, chatID int64, eventID string) (string, error) {
+	nonce, ok := f.nonces[registrationKey{chatID: chatID, eventID: eventID}]
+	if !ok {
+		return "", models.ErrRegistrationNotFound
+	}
+	return nonce, nil
+}
+
+// fakeCheckIns хранилище отметок посещения для тестов
+type fakeCheckIns struct {
+	saved []models.CheckIn
+}
+
+func (f *fakeCheckIns) SaveCheckIn(_ context.Context, checkIn models.CheckIn) (models.CheckIn, error) {
+	for _, prev := range f.saved {
+		if prev.EventID == checkIn.EventID && prev.ChatID == checkIn.ChatID {
+			prev.Duplicate = true
+			return prev, nil
+		}
+	}
+	f.saved = append(f.saved, checkIn)
+	return checkIn, nil
+}
+
+// fakeUserData данные пользователей для тестов, неиспользуемые методы не реализованы
+type fakeUserData struct {
+	UserDataKeeper
+}
+
+func (fakeUserData) GetUser(_ context.Context, chatID int64) (*models.User, error) {
+	return &models.User{ChatID: chatID, Username: "user"}, nil
+}
+
+func TestCheckIn(t *testing.T) {
+	const (
+		eventID = "event-1"
+		chatID  = int64(42)
+		staffID = int64(7)
+	)
+
+	// issuedNonce подставляется вместо nonce, сохранённого при выдаче билета
+	const issuedNonce = "issued"
+
+	tests := []struct {
+		name string
+		// nonce в регистрации участника
+		nonce      string
+		registered bool
+		// secret секрет, которым подписан билет
+		secret  string
+		wantErr error
+	}{
+		{name: "current ticket", nonce: issuedNonce, registered: true, secret: "secret"},
+		{name: "legacy ticket without stored nonce", nonce: "", registered: true, secret: "secret"},
+		{name: "reissued ticket", nonce: "other", registered: true, secret: "secret", wantErr: models.ErrTicketRevoked},
+		{name: "no active registration", secret: "secret", wantErr: models.ErrRegistrationNotFound},
+		{name: "forged ticket", nonce: issuedNonce, registered: true, secret: "other", wantErr: ticket.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := registrationKey{chatID: chatID, eventID: eventID}
+			registrations := &fakeRegistrations{nonces: map[registrationKey]string{key: ""}}
+			newService := func(secret string) *Service {
+				return NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), Deps{
+					Tickets:       ticket.NewSigner(secret),
+					Registrations: registrations,
+					CheckIns:      &fakeCheckIns{},
+					UserData:      fakeUserData{},
+				})
+			}
+			s := newService("secret")
+
+			code, err := newService(tt.secret).IssueTicket(context.Background(), eventID, chatID)
+			if err != nil {
+				t.Fatalf("issue: %v", err)
+			}
+			switch {
+			case !tt.registered:
+				delete(registrations.nonces, key)
+			case tt.nonce != issuedNonce:
+				registrations.nonces[key] = tt.nonce
+			}
+
+			checkIn, err := s.CheckIn(context.Background(), code, staffID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if checkIn.ChatID != chatID || checkIn.CheckedInBy != staffID || checkIn.Username != "user" {
+				t.Errorf("got %+v", checkIn)
+			}
+
+			again, err := s.CheckIn(context.Background(), code, staffID)
+			if err != nil || !again.Duplicate {
+				t.Errorf("second scan: got %+v, %v, want duplicate", again, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opGetFilteredEvents = "service.GetFilteredEvents"
+	opSetUserTimezone   = "service.SetUserTimezone"
+)
+
+// UserSettingsKeeper определяет методы для хранения настроек пользователя
+type UserSettingsKeeper interface {
+	GetUserTimezone(ctx context.Context, chatID int64) (string, error)
+	SetUserTimezone(ctx context.Context, chatID int64, timezone string) error
+}
+
+// DateRange описывает полуинтервал дат [From, To) и его название для отображения пользователю
+type DateRange struct {
+	From  time.Time
+	To    time.Time
+	Label string
+}
+
+// ParseDateFilter вычисляет диапазон дат для кода фильтра относительно текущего момента в часовом поясе loc.
+// Диапазоны текущих периодов начинаются с начала сегодняшнего дня, а не с текущего момента: уже начавшиеся события
+// отсекаются так же, как в списке всех событий, с учётом pastEventsGrace
+func ParseDateFilter(code string, now time.Time, loc *time.Location) (DateRange, error) {
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	// Дней до конца недели (воскресенья включительно), неделя начинается с понедельника
+	daysToMonday := (7 - int(today.Weekday()) + 1) % 7
+	if daysToMonday == 0 {
+		daysToMonday = 7
+	}
+	nextMonday := today.AddDate(0, 0, daysToMonday)
+
+	switch code {
+	case models.FilterToday:
+		return DateRange{From: today, To: today.AddDate(0, 0, 1), Label: "Сегодня"}, nil
+	case models.FilterWeek:
+		return DateRange{From: today, To: nextMonday, Label: "Эта неделя"}, nil
+	case models.FilterWeekend:
+		saturday := nextMonday.AddDate(0, 0, -2)
+		if today.After(saturday) {
+			saturday = today
+		}
+		return DateRange{From: saturday, To: nextMonday, Label: "Эти выходные"}, nil
+	case models.FilterMonth:
+		nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, loc)
+		return DateRange{From: today, To: nextMonth, Label: "Этот месяц"}, nil
+	}
+
+	if raw, ok := strings.CutPrefix(code, models.FilterCustomPrefix); ok {
+		fromRaw, toRaw, found := strings.Cut(raw, "-")
+		if !found {
+			return DateRange{}, models.ErrInvalidFilter
+		}
+		from, err := time.ParseInLocation(models.FilterCustomLayout, fromRaw, loc)
+		if err != nil {
+			return DateRange{}, models.ErrInvalidFilter
+		}
+		to, err := time.ParseInLocation(models.FilterCustomLayout, toRaw, loc)
+		if err != nil || to.Before(from) {
+			return DateRange{}, models.ErrInvalidFilter
+		}
+		return DateRange{
+			From:  from,
+			To:    to.AddDate(0, 0, 1),
+			Label: from.Format("02.01.2006") + " — " + to.Format("02.01.2006"),
+		}, nil
+	}
+
+	return DateRange{}, models.ErrInvalidFilter
+}
+
+// GetFilteredEvents возвращает предстоящие события, начинающиеся в диапазоне дат фильтра в часовом поясе loc
+// пользователя. Для фильтра models.FilterAll возвращаются все предстоящие события
+func (s *Service) GetFilteredEvents(ctx context.Context, code string, loc *time.Location) ([]*pb.Event, string, error) {
+	events, err := s.GetEvents(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", opGetFilteredEvents, err)
+	}
+
+	if code == models.FilterAll || code == "" {
+		return events, "", nil
+	}
+
+	dateRange, err := ParseDateFilter(code, time.Now(), loc)
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("filter", code), slog.String("operation", opGetFilteredEvents))
+		return nil, "", err
+	}
+
+	filtered := make([]*pb.Event, 0, len(events))
+	for _, e := range events {
+		if e.GetStartsAt() == nil {
+			continue
+		}
+		startsAt := e.GetStartsAt().AsTime()
+		if !startsAt.Before(dateRange.From) && startsAt.Before(dateRange.To) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered, dateRange.Label, nil
+}
+
+// UserLocation возвращает часовой пояс пользователя, при его отсутствии - часовой пояс по умолчанию
+func (s *Service) UserLocation(ctx context.Context, chatID int64) *time.Location {
+	timezone, err := s.userSettings.GetUserTimezone(ctx, chatID)
+	if err != nil {
+		if !errors.Is(err, models.ErrUserNotFound) {
+			s.log.WarnContext(ctx, "failed to get user timezone", slog.Int64("chat_id", chatID), logger.Err(err))
+		}
+		return s.defaultLocation
+	}
+	if timezone == "" {
+		return s.defaultLocation
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return s.defaultLocation
+	}
+	return loc
+}
+
+// SetUserTimezone проводит валидацию и сохраняет часовой пояс пользователя (в формате IANA, например Europe/Moscow)
+func (s *Service) SetUserTimezone(ctx context.Context, chatID int64, timezone string) error {
+	if err := validateChatID(chatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opSetUserTimezone))
+		return err
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || strings.EqualFold(timezone, "local") {
+		return fmt.Errorf("unknown timezone %q", timezone)
+	}
+
+	if err := s.userSettings.SetUserTimezone(ctx, chatID, timezone); err != nil {
+		return fmt.Errorf("%s: %w", opSetUserTimezone, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestParseDateFilter(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, moscow)
+	}
+	wednesday := at(2026, time.October, 21, 15)
+
+	tests := []struct {
+		name     string
+		code     string
+		now      time.Time
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  error
+	}{
+		{name: "today starts at midnight", code: models.FilterToday, now: wednesday, wantFrom: at(2026, time.October, 21, 0), wantTo: at(2026, time.October, 22, 0)},
+		{name: "today in user timezone", code: models.FilterToday, now: time.Date(2026, time.October, 21, 22, 30, 0, 0, time.UTC), wantFrom: at(2026, time.October, 22, 0), wantTo: at(2026, time.October, 23, 0)},
+		{name: "week ends on sunday", code: models.FilterWeek, now: wednesday, wantFrom: at(2026, time.October, 21, 0), wantTo: at(2026, time.October, 26, 0)},
+		{name: "week on sunday", code: models.FilterWeek, now: at(2026, time.October, 25, 10), wantFrom: at(2026, time.October, 25, 0), wantTo: at(2026, time.October, 26, 0)},
+		{name: "week on monday", code: models.FilterWeek, now: at(2026, time.October, 26, 10), wantFrom: at(2026, time.October, 26, 0), wantTo: at(2026, time.November, 2, 0)},
+		{name: "weekend ahead", code: models.FilterWeekend, now: wednesday, wantFrom: at(2026, time.October, 24, 0), wantTo: at(2026, time.October, 26, 0)},
+		{name: "weekend on saturday", code: models.FilterWeekend, now: at(2026, time.October, 24, 18), wantFrom: at(2026, time.October, 24, 0), wantTo: at(2026, time.October, 26, 0)},
+		{name: "weekend on sunday", code: models.FilterWeekend, now: at(2026, time.October, 25, 18), wantFrom: at(2026, time.October, 25, 0), wantTo: at(2026, time.October, 26, 0)},
+		{name: "month at year end", code: models.FilterMonth, now: at(2026, time.December, 31, 23), wantFrom: at(2026, time.December, 31, 0), wantTo: at(2027, time.January, 1, 0)},
+		{name: "custom range includes last day", code: "c20261101-20261103", now: wednesday, wantFrom: at(2026, time.November, 1, 0), wantTo: at(2026, time.November, 4, 0)},
+		{name: "custom single day", code: "c20261101-20261101", now: wednesday, wantFrom: at(2026, time.November, 1, 0), wantTo: at(2026, time.November, 2, 0)},
+		{name: "custom reversed", code: "c20261103-20261101", now: wednesday, wantErr: models.ErrInvalidFilter},
+		{name: "custom invalid date", code: "c20261301-20261302", now: wednesday, wantErr: models.ErrInvalidFilter},
+		{name: "custom without separator", code: "c20261101", now: wednesday, wantErr: models.ErrInvalidFilter},
+		{name: "unknown code", code: "year", now: wednesday, wantErr: models.ErrInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateFilter(tt.code, tt.now, moscow)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !got.From.Equal(tt.wantFrom) || !got.To.Equal(tt.wantTo) {
+				t.Errorf("got [%v, %v), want [%v, %v)", got.From, got.To, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestGetFilteredEventsMatchesUpcomingList(t *testing.T) {
+	now := time.Now()
+	event := func(id string, startsAt time.Time) *pb.Event {
+		return &pb.Event{Id: id, StartsAt: timestamppb.New(startsAt)}
+	}
+
+	s := NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), Deps{
+		EventReceiver: &fakeCatalog{events: []*pb.Event{
+			event("started", now.Add(-10*time.Minute)),
+			event("long ago", now.Add(-3*time.Hour)),
+			event("soon", now.Add(time.Minute)),
+		}},
+		PastEventsGrace: time.Hour,
+	})
+
+	all, _, err := s.GetFilteredEvents(context.Background(), models.FilterAll, time.UTC)
+	if err != nil {
+		t.Fatalf("all: %v", err)
+	}
+	// Событие, начавшееся в пределах pastEventsGrace, показывается и в фильтре по дате, если оно началось сегодня
+	today, _, err := s.GetFilteredEvents(context.Background(), models.FilterToday, time.UTC)
+	if err != nil {
+		t.Fatalf("today: %v", err)
+	}
+
+	contains := func(events []*pb.Event, id string) bool {
+		for _, e := range events {
+			if e.GetId() == id {
+				return true
+			}
+		}
+		return false
+	}
+	if !contains(all, "started") || contains(all, "long ago") {
+		t.Fatalf("unexpected upcoming list %v", all)
+	}
+	startedToday := now.Add(-10*time.Minute).UTC().Day() == now.UTC().Day()
+	if contains(today, "started") != startedToday {
+		t.Errorf("started event in today filter = %v, want %v", contains(today, "started"), startedToday)
+	}
+	if contains(today, "long ago") {
+		t.Error("event outside grace window shown in today filter")
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opGetEventExtras    = "service.GetEventExtras"
+	opUpdateEventExtras = "service.UpdateEventExtras"
+)
+
+// clearValue значение, которым администратор очищает поле дополнительной информации
+const clearValue = "-"
+
+// urlRegexp находит ссылки в описании события
+var urlRegexp = regexp.MustCompile(`https?://[^\s()<>«»"]+`)
+
+// urlTrailingPunct знаки препинания, которые в тексте идут сразу за ссылкой, но не являются её частью
+const urlTrailingPunct = ".,;:!?'…"
+
+// EventExtrasKeeper определяет методы для хранения дополнительной информации о событиях
+type EventExtrasKeeper interface {
+	GetEventExtras(ctx context.Context, eventID string) (models.EventExtras, error)
+	SaveEventExtras(ctx context.Context, extras models.EventExtras) error
+}
+
+// GetEventExtras возвращает дополнительную информацию о событии.
+// Если внешняя ссылка не задана администратором, берётся первая ссылка из описания события
+func (s *Service) GetEventExtras(ctx context.Context, event *pb.Event) (models.EventExtras, error) {
+	extras, err := s.eventExtras.GetEventExtras(ctx, event.GetId())
+	if err != nil {
+		return models.EventExtras{EventID: event.GetId()}, fmt.Errorf("%s: %w", opGetEventExtras, err)
+	}
+
+	if extras.URL == "" {
+		extras.URL = descriptionURL(event.GetDescription())
+	}
+	return extras, nil
+}
+
+// descriptionURL возвращает первую корректную http(s)-ссылку из описания события без завершающих знаков препинания
+func descriptionURL(description string) string {
+	for _, candidate := range urlRegexp.FindAllString(description, -1) {
+		candidate = strings.TrimRight(candidate, urlTrailingPunct)
+		if isWebURL(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// isWebURL проверяет, что value - абсолютная http(s)-ссылка с указанием хоста
+func isWebURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// UpdateEventExtras проводит валидацию и обновляет указанные поля дополнительной информации о событии.
+// Поддерживаемые поля: photo, address, location (широта,долгота), url. Значение "-" очищает поле
+func (s *Service) UpdateEventExtras(ctx context.Context, eventID string, fields map[string]string) (models.EventExtras, error) {
+	if err := validateEventID(eventID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opUpdateEventExtras))
+		return models.EventExtras{}, err
+	}
+
+	extras, err := s.eventExtras.GetEventExtras(ctx, eventID)
+	if err != nil {
+		return models.EventExtras{}, fmt.Errorf("%s: %w", opUpdateEventExtras, err)
+	}
+
+	for key, value := range fields {
+		value = strings.TrimSpace(value)
+		reset := value == clearValue
+
+		switch key {
+		case "photo":
+			extras.Photo = value
+			if reset {
+				extras.Photo = ""
+			}
+		case "address":
+			extras.Address = value
+			if reset {
+				extras.Address = ""
+			}
+		case "location":
+			if reset {
+				extras.Latitude, extras.Longitude = nil, nil
+				continue
+			}
+			lat, lon, err := parseLocation(value)
+			if err != nil {
+				return models.EventExtras{}, err
+			}
+			extras.Latitude, extras.Longitude = &lat, &lon
+		case "url":
+			if reset {
+				extras.URL = ""
+				continue
+			}
+			if !isWebURL(value) {
+				return models.EventExtras{}, fmt.Errorf("%w: %q", models.ErrInvalidURL, value)
+			}
+			extras.URL = value
+		default:
+			return models.EventExtras{}, fmt.Errorf("%w: %q, allowed: photo, address, location, url", models.ErrUnknownEventField, key)
+		}
+	}
+
+	if err = s.eventExtras.SaveEventExtras(ctx, extras); err != nil {
+		return models.EventExtras{}, fmt.Errorf("%s: %w", opUpdateEventExtras, err)
+	}
+	return extras, nil
+}
+
+// parseLocation разбирает координаты в формате "широта,долгота"
+func parseLocation(value string) (float64, float64, error) {
+	latRaw, lonRaw, ok := strings.Cut(value, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: must be in format latitude,longitude", models.ErrInvalidLocation)
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latRaw), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("%w: latitude %q", models.ErrInvalidLocation, latRaw)
+	}
+
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonRaw), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("%w: longitude %q", models.ErrInvalidLocation, lonRaw)
+	}
+	return lat, lon, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+func TestDescriptionURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{name: "no link", description: "Подробности позже", want: ""},
+		{name: "trailing dot", description: "Подробнее: https://example.com/event.", want: "https://example.com/event"},
+		{name: "trailing comma", description: "Сайт https://example.com/event, вход свободный", want: "https://example.com/event"},
+		{name: "quotes", description: "Ссылка «https://example.com/event?id=1»", want: "https://example.com/event?id=1"},
+		{name: "parentheses", description: "Регистрация (https://example.com/r)", want: "https://example.com/r"},
+		{name: "first valid link", description: "http:// и https://example.com!", want: "https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := descriptionURL(tt.description); got != tt.want {
+				t.Errorf("descriptionURL(%q) = %q, want %q", tt.description, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantLat float64
+		wantLon float64
+		wantErr error
+	}{
+		{name: "valid", value: "55.7539, 37.6208", wantLat: 55.7539, wantLon: 37.6208},
+		{name: "bounds", value: "-90,180", wantLat: -90, wantLon: 180},
+		{name: "no separator", value: "55.7539 37.6208", wantErr: models.ErrInvalidLocation},
+		{name: "latitude out of range", value: "91,37", wantErr: models.ErrInvalidLocation},
+		{name: "longitude not a number", value: "55,east", wantErr: models.ErrInvalidLocation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lon, err := parseLocation(tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (lat != tt.wantLat || lon != tt.wantLon) {
+				t.Errorf("got (%v, %v), want (%v, %v)", lat, lon, tt.wantLat, tt.wantLon)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opDetectEventChanges = "service.DetectEventChanges"
+)
+
+// EventChangeSource описывает источник изменений событий в микросервисе событий.
+// Уведомления о возвращённых изменениях уже поставлены в очередь рассылок
+type EventChangeSource interface {
+	Changes(ctx context.Context) ([]models.EventChange, error)
+}
+
+// DetectEventChanges находит изменённые и отменённые события и ставит в очередь рассылок уведомления
+// для зарегистрированных на них пользователей. Уведомления отправляются задачей рассылки
+func (s *Service) DetectEventChanges(ctx context.Context) error {
+	if s.eventChanges == nil {
+		return nil
+	}
+
+	changes, err := s.eventChanges.Changes(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDetectEventChanges, err)
+	}
+	if len(changes) > 0 {
+		s.log.InfoContext(ctx, "event change notices queued", slog.Int("changes", len(changes)), slog.String("operation", opDetectEventChanges))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opQueueFeedbackSurveys = "service.QueueFeedbackSurveys"
+	opRateEvent            = "service.RateEvent"
+	opCommentEvent         = "service.CommentEvent"
+	opGetFeedbackSummary   = "service.GetFeedbackSummary"
+)
+
+// Ограничения опросов
+const (
+	// feedbackSurveyMaxAge события, завершившиеся раньше, не опрашиваются (например, при первом запуске)
+	feedbackSurveyMaxAge = 72 * time.Hour
+	// maxCommentLength максимальная длина комментария в символах
+	maxCommentLength = 1000
+)
+
+// FeedbackKeeper определяет методы для хранения опросов и отзывов о событиях
+type FeedbackKeeper interface {
+	QueueFeedbackSurvey(ctx context.Context, survey models.FeedbackSurvey) (bool, error)
+	SaveFeedbackRating(ctx context.Context, eventID string, chatID int64, rating int) error
+	SaveFeedbackComment(ctx context.Context, eventID string, chatID int64, comment string) error
+	GetFeedbackSummary(ctx context.Context, eventID string) (models.FeedbackSummary, error)
+}
+
+// QueueFeedbackSurveys находит завершившиеся события, по которым ещё не отправлялся опрос,
+// и ставит опросы участникам в очередь рассылок. Опросы отправляются задачей рассылки
+func (s *Service) QueueFeedbackSurveys(ctx context.Context) error {
+	events, err := s.eventReceiver.GetEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opQueueFeedbackSurveys, err)
+	}
+
+	now := time.Now()
+	for _, e := range events {
+		if e.GetStartsAt() == nil {
+			continue
+		}
+
+		endsAt := e.GetStartsAt().AsTime().Add(s.eventDuration)
+		if endsAt.After(now) || now.Sub(endsAt) > feedbackSurveyMaxAge {
+			continue
+		}
+
+		queued, err := s.feedback.QueueFeedbackSurvey(ctx, models.FeedbackSurvey{EventID: e.GetId(), EventTitle: e.GetTitle()})
+		if err != nil {
+			return fmt.Errorf("%s: %w", opQueueFeedbackSurveys, err)
+		}
+		if queued {
+			s.log.InfoContext(ctx, "feedback survey queued", slog.String("event_id", e.GetId()), slog.String("operation", opQueueFeedbackSurveys))
+		}
+	}
+	return nil
+}
+
+// RateEvent проводит валидацию и сохраняет оценку события
+func (s *Service) RateEvent(ctx context.Context, eventID string, chatID int64, rating int) error {
+	if err := validateEventID(eventID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opRateEvent))
+		return err
+	}
+
+	if rating < 1 || rating > 5 {
+		err := errors.New("rating must be between 1 and 5")
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opRateEvent))
+		return err
+	}
+
+	if err := s.feedback.SaveFeedbackRating(ctx, eventID, chatID, rating); err != nil {
+		return fmt.Errorf("%s: %w", opRateEvent, err)
+	}
+	return nil
+}
+
+// CommentEvent проводит валидацию и сохраняет комментарий к оценке события
+func (s *Service) CommentEvent(ctx context.Context, eventID string, chatID int64, comment string) error {
+	if err := validateEventID(eventID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opCommentEvent))
+		return err
+	}
+
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(comment) > maxCommentLength {
+		comment = string([]rune(comment)[:maxCommentLength])
+	}
+
+	if err := s.feedback.SaveFeedbackComment(ctx, eventID, chatID, comment); err != nil {
+		return fmt.Errorf("%s: %w", opCommentEvent, err)
+	}
+	return nil
+}
+
+// GetFeedbackSummary возвращает агрегированные отзывы о событии
+func (s *Service) GetFeedbackSummary(ctx context.Context, eventID string) (models.FeedbackSummary, error) {
+	if err := validateEventID(eventID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opGetFeedbackSummary))
+		return models.FeedbackSummary{}, err
+	}
+
+	summary, err := s.feedback.GetFeedbackSummary(ctx, eventID)
+	if err != nil {
+		return summary, fmt.Errorf("%s: %w", opGetFeedbackSummary, err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opClaimNotifications   = "service.ClaimNotifications"
+	opCompleteNotification = "service.CompleteNotification"
+	opFailNotification     = "service.FailNotification"
+)
+
+const (
+	// notificationBatchSize сколько уведомлений берётся из очереди рассылок за раз
+	notificationBatchSize = 100
+	// notificationLease через сколько взятое, но не отправленное уведомление снова попадёт в рассылку,
+	// если бот остановится или время задачи истечёт
+	notificationLease = 5 * time.Minute
+	// maxNotificationAttempts число попыток, после которого уведомление считается недоставленным
+	maxNotificationAttempts = 5
+)
+
+// NotificationOutbox определяет методы очереди рассылок: уведомления ставятся в неё вместе с событием,
+// которое их вызвало, и отмечаются отправленными по одному, поэтому прерванная рассылка продолжается со следующего запуска
+type NotificationOutbox interface {
+	ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]models.Notification, error)
+	CompleteNotification(ctx context.Context, id int64) error
+	FailNotification(ctx context.Context, id int64, lastError string) error
+}
+
+// ClaimNotifications возвращает очередную партию уведомлений, ожидающих отправки
+func (s *Service) ClaimNotifications(ctx context.Context) ([]models.Notification, error) {
+	notifications, err := s.notifications.ClaimNotifications(ctx, notificationBatchSize, notificationLease)
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opClaimNotifications))
+		return nil, fmt.Errorf("%s: %w", opClaimNotifications, err)
+	}
+	return notifications, nil
+}
+
+// CompleteNotification отмечает уведомление доставленным
+func (s *Service) CompleteNotification(ctx context.Context, n models.Notification) error {
+	if err := s.notifications.CompleteNotification(ctx, n.ID); err != nil {
+		return fmt.Errorf("%s: %w", opCompleteNotification, err)
+	}
+	return nil
+}
+
+// FailNotification обрабатывает ошибку отправки уведомления. Уведомление отмечается недоставленным,
+// если ошибка окончательная (например, пользователь заблокировал бота) или попытки исчерпаны,
+// иначе оно будет отправлено повторно после окончания аренды
+func (s *Service) FailNotification(ctx context.Context, n models.Notification, sendErr error, permanent bool) error {
+	if !permanent && n.Attempts < maxNotificationAttempts {
+		return nil
+	}
+	if err := s.notifications.FailNotification(ctx, n.ID, sendErr.Error()); err != nil {
+		return fmt.Errorf("%s: %w", opFailNotification, err)
+	}
+	s.log.WarnContext(ctx, "notification was not delivered",
+		slog.Int64("chat_id", n.ChatID),
+		slog.String("kind", string(n.Kind)),
+		slog.Int("attempts", n.Attempts),
+		slog.String("operation", opFailNotification),
+	)
+	return nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opDeliverQueuedRegistrations = "service.DeliverQueuedRegistrations"
+)
+
+const (
+	// outboxBatchSize сколько заявок отправляется за один запуск задачи
+	outboxBatchSize = 50
+	// outboxLease на сколько откладывается следующая попытка взятой в работу заявки,
+	// если бот остановится, не закончив отправку
+	outboxLease = 5 * time.Minute
+	// maxOutboxBackoff наибольшая пауза между попытками отправки заявки
+	maxOutboxBackoff = time.Hour
+	// saveRegistrationTimeout сколько времени даётся на сохранение результата регистрации,
+	// если время запроса истекло, пока микросервис событий не отвечал
+	saveRegistrationTimeout = 5 * time.Second
+)
+
+// RegistrationOutbox определяет методы очереди заявок на регистрацию, ожидающих доступности микросервиса событий
+type RegistrationOutbox interface {
+	EnqueueRegistration(ctx context.Context, req models.RegistrationRequest) (models.RegistrationOutcome, error)
+	ClaimQueuedRegistrations(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEntry, error)
+	CompleteQueuedRegistration(ctx context.Context, id int64, reg models.Registration, outcome models.RegistrationOutcome) error
+	RetryQueuedRegistration(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error
+	FailQueuedRegistration(ctx context.Context, id int64, lastError string) error
+}
+
+// OutboxPolicy описывает повторные попытки отправки заявок из очереди
+type OutboxPolicy struct {
+	// MaxAttempts число попыток, после которого заявка считается недоставленной
+	MaxAttempts int
+	// RetryBackoff пауза перед первой повторной попыткой, с каждой следующей она удваивается
+	RetryBackoff time.Duration
+}
+
+// retryDelay возвращает паузу перед следующей попыткой после attempts неудачных
+func (p OutboxPolicy) retryDelay(attempts int) time.Duration {
+	delay := p.RetryBackoff
+	for i := 1; i < attempts && delay < maxOutboxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxOutboxBackoff)
+}
+
+// DeliverQueuedRegistrations отправляет в микросервис событий заявки из очереди, время которых подошло.
+// Возвращает заявки с окончательным результатом, о котором нужно сообщить пользователям;
+// заявки, которые будут отправлены повторно, в результат не попадают
+func (s *Service) DeliverQueuedRegistrations(ctx context.Context) ([]models.RegistrationDelivery, error) {
+	if s.outbox == nil {
+		return nil, nil
+	}
+
+	entries, err := s.outbox.ClaimQueuedRegistrations(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opDeliverQueuedRegistrations))
+		return nil, fmt.Errorf("%s: %w", opDeliverQueuedRegistrations, err)
+	}
+
+	var deliveries []models.RegistrationDelivery
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			// Оставшиеся заявки вернутся в работу после окончания аренды
+			break
+		}
+		outcome, err := s.deliverQueuedRegistration(ctx, e)
+		if err != nil {
+			s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opDeliverQueuedRegistrations))
+			continue
+		}
+		if outcome != models.RegistrationQueued {
+			deliveries = append(deliveries, models.RegistrationDelivery{Request: e.Request, Outcome: outcome})
+		}
+	}
+	return deliveries, nil
+}
+
+// deliverQueuedRegistration отправляет одну заявку из очереди и записывает результат.
+// Возвращает models.RegistrationQueued, если заявка будет отправлена повторно
+func (s *Service) deliverQueuedRegistration(ctx context.Context, e models.OutboxEntry) (models.RegistrationOutcome, error) {
+	req := e.Request
+	attrs := []any{
+		slog.Int64("outbox_id", e.ID),
+		slog.String("event_id", req.EventID),
+		slog.Int64("chat_id", req.ChatID),
+		slog.Int("attempt", e.Attempts),
+		slog.String("operation", opDeliverQueuedRegistrations),
+	}
+
+	result, err := s.userRegister.RegisterUser(ctx, req.EventID, req.ChatID, req.Username, req.IdempotencyKey)
+	switch {
+	case err == nil && result, errors.Is(err, models.ErrAlreadyRegistered):
+		// Повтор с тем же ключом идемпотентности после потерянного ответа тоже приходит сюда
+		outcome := models.RegistrationConfirmed
+		if err != nil {
+			outcome = models.RegistrationAlreadyExists
+		}
+		reg := models.Registration{ChatID: req.ChatID, EventID: req.EventID, Source: models.RegistrationSourceBot, CreatedAt: e.CreatedAt}
+		if err = s.outbox.CompleteQueuedRegistration(ctx, e.ID, reg, outcome); err != nil {
+			return "", err
+		}
+		s.log.InfoContext(ctx, "queued registration delivered", append(attrs, slog.String("outcome", string(outcome)))...)
+		return outcome, nil
+	case err == nil, errors.Is(err, models.ErrRequestRejected):
+		// Отклонённая заявка не ставится на повтор: следующая попытка получит тот же ответ
+		lastError := "registration rejected by event service"
+		if err != nil {
+			lastError = err.Error()
+		}
+		if failErr := s.outbox.FailQueuedRegistration(ctx, e.ID, lastError); failErr != nil {
+			return "", failErr
+		}
+		s.log.WarnContext(ctx, "queued registration rejected", append(attrs, slog.String("last_error", lastError))...)
+		return models.RegistrationRejected, nil
+	case e.Attempts < s.outboxPolicy.MaxAttempts:
+		next := time.Now().Add(s.outboxPolicy.retryDelay(e.Attempts))
+		if retryErr := s.outbox.RetryQueuedRegistration(ctx, e.ID, next, err.Error()); retryErr != nil {
+			return "", retryErr
+		}
+		s.log.WarnContext(ctx, "queued registration will be retried", append(attrs, logger.Err(err), slog.Time("next_attempt_at", next))...)
+		return models.RegistrationQueued, nil
+	default:
+		if failErr := s.outbox.FailQueuedRegistration(ctx, e.ID, err.Error()); failErr != nil {
+			return "", failErr
+		}
+		s.log.ErrorContext(ctx, "queued registration failed", append(attrs, logger.Err(err))...)
+		return models.RegistrationFailed, nil
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+func TestOutboxPolicyRetryDelay(t *testing.T) {
+	policy := OutboxPolicy{MaxAttempts: 10, RetryBackoff: time.Minute}
+
+	tests := []struct {
+		name     string
+		policy   OutboxPolicy
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first retry", policy: policy, attempts: 1, want: time.Minute},
+		{name: "doubles", policy: policy, attempts: 2, want: 2 * time.Minute},
+		{name: "doubles again", policy: policy, attempts: 4, want: 8 * time.Minute},
+		{name: "capped", policy: policy, attempts: 7, want: maxOutboxBackoff},
+		{name: "capped for many attempts", policy: policy, attempts: 1000, want: maxOutboxBackoff},
+		{name: "zero attempts", policy: policy, attempts: 0, want: time.Minute},
+		{name: "backoff above cap", policy: OutboxPolicy{RetryBackoff: 2 * time.Hour}, attempts: 1, want: maxOutboxBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.retryDelay(tt.attempts); got != tt.want {
+				t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeRegister микросервис событий для тестов, отвечающий заданной ошибкой
+type fakeRegister struct {
+	err   error
+	calls int
+}
+
+func (f *fakeRegister) RegisterUser(context.Context, string, int64, string, string) (bool, error) {
+	f.calls++
+	return f.err == nil, f.err
+}
+
+// fakeOutbox очередь заявок для тестов, запоминающая результат последней обработанной заявки
+type fakeOutbox struct {
+	RegistrationOutbox
+	// stored результат, возвращаемый при постановке заявки в очередь
+	stored    models.RegistrationOutcome
+	completed models.RegistrationOutcome
+	failed    bool
+	retried   bool
+}
+
+func (f *fakeOutbox) EnqueueRegistration(context.Context, models.RegistrationRequest) (models.RegistrationOutcome, error) {
+	return f.stored, nil
+}
+
+func (f *fakeOutbox) CompleteQueuedRegistration(_ context.Context, _ int64, _ models.Registration, outcome models.RegistrationOutcome) error {
+	f.completed = outcome
+	return nil
+}
+
+func (f *fakeOutbox) RetryQueuedRegistration(context.Context, int64, time.Time, string) error {
+	f.retried = true
+	return nil
+}
+
+func (f *fakeOutbox) FailQueuedRegistration(context.Context, int64, string) error {
+	f.failed = true
+	return nil
+}
+
+func TestDeliverQueuedRegistration(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		attempts    int
+		want        models.RegistrationOutcome
+		wantRetried bool
+		wantFailed  bool
+	}{
+		{name: "confirmed", want: models.RegistrationConfirmed},
+		{name: "already registered", err: models.ErrAlreadyRegistered, attempts: 1, want: models.RegistrationAlreadyExists},
+		{name: "unavailable is retried", err: models.ErrEventServiceUnavailable, attempts: 1, want: models.RegistrationQueued, wantRetried: true},
+		{name: "unavailable after last attempt", err: models.ErrEventServiceUnavailable, attempts: 3, want: models.RegistrationFailed, wantFailed: true},
+		{name: "rejected on first attempt", err: fmt.Errorf("not found: %w", models.ErrRequestRejected), attempts: 1, want: models.RegistrationRejected, wantFailed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &fakeOutbox{}
+			s := NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), Deps{
+				UserRegister: &fakeRegister{err: tt.err},
+				Outbox:       outbox,
+				OutboxPolicy: OutboxPolicy{MaxAttempts: 3, RetryBackoff: time.Minute},
+			})
+
+			entry := models.OutboxEntry{ID: 1, Attempts: tt.attempts, Request: models.RegistrationRequest{EventID: "1", ChatID: 42}}
+			got, err := s.deliverQueuedRegistration(context.Background(), entry)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("outcome = %q, want %q", got, tt.want)
+			}
+			if outbox.retried != tt.wantRetried || outbox.failed != tt.wantFailed {
+				t.Errorf("retried = %v, failed = %v, want %v, %v", outbox.retried, outbox.failed, tt.wantRetried, tt.wantFailed)
+			}
+			if (got == models.RegistrationConfirmed || got == models.RegistrationAlreadyExists) && outbox.completed != got {
+				t.Errorf("stored outcome = %q, want %q", outbox.completed, got)
+			}
+		})
+	}
+}
+
+func TestRegisterUserReturnsStoredOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored models.RegistrationOutcome
+	}{
+		{name: "queued", stored: models.RegistrationQueued},
+		{name: "still pending", stored: models.RegistrationAlreadyQueued},
+		{name: "delivered", stored: models.RegistrationConfirmed},
+		{name: "delivered to registered user", stored: models.RegistrationAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), Deps{
+				UserRegister: &fakeRegister{err: models.ErrEventServiceUnavailable},
+				Outbox:       &fakeOutbox{stored: tt.stored},
+			})
+
+			got, err := s.RegisterUser(context.Background(), models.RegistrationRequest{EventID: "1", ChatID: 42, Username: "username"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.stored {
+				t.Errorf("outcome = %q, want %q", got, tt.stored)
+			}
+		})
+	}
+}
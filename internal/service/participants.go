@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opExportParticipants = "service.ExportParticipants"
+)
+
+// participantRow описывает участника события, дополненного данными из базы данных бота
+type participantRow struct {
+	registrant models.Registrant
+	user       *models.User
+}
+
+// value возвращает значение указанной колонки для участника
+func (r participantRow) value(column string) string {
+	switch column {
+	case "chat_id":
+		return strconv.FormatInt(r.registrant.ChatID, 10)
+	case "username":
+		if r.user != nil && r.user.Username != "" {
+			return r.user.Username
+		}
+		return r.registrant.Username
+	case "first_name":
+		if r.user != nil {
+			return r.user.FirstName
+		}
+	case "last_name":
+		if r.user != nil {
+			return r.user.LastName
+		}
+	case "language_code":
+		if r.user != nil {
+			return r.user.LanguageCode
+		}
+	case "registered_at":
+		if !r.registrant.RegisteredAt.IsZero() {
+			return r.registrant.RegisteredAt.Format(time.DateTime)
+		}
+	}
+	return ""
+}
+
+// ExportParticipants получает участников события, дополняет их данными пользователей из базы данных
+// и возвращает CSV-файл с выбранными колонками (если колонки не указаны - со всеми).
+// Если микросервис событий не отдаёт список участников, он строится по локальной копии регистраций
+func (s *Service) ExportParticipants(ctx context.Context, eventID string, columns []string) ([]byte, error) {
+	if err := validateEventID(eventID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opExportParticipants))
+		return nil, err
+	}
+
+	columns, err := validateColumns(columns)
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opExportParticipants))
+		return nil, err
+	}
+
+	registrants, err := s.eventReceiver.GetRegistrants(ctx, eventID)
+	if errors.Is(err, models.ErrNotSupported) {
+		registrants, err = s.registrations.GetEventRegistrants(ctx, eventID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportParticipants, err)
+	}
+
+	chatIDs := make([]int64, 0, len(registrants))
+	for _, r := range registrants {
+		chatIDs = append(chatIDs, r.ChatID)
+	}
+
+	users, err := s.userData.GetUsers(ctx, chatIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportParticipants, err)
+	}
+
+	byChatID := make(map[int64]*models.User, len(users))
+	for i := range users {
+		byChatID[users[i].ChatID] = &users[i]
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err = w.Write(columns); err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportParticipants, err)
+	}
+
+	for _, r := range registrants {
+		row := participantRow{registrant: r, user: byChatID[r.ChatID]}
+		record := make([]string, 0, len(columns))
+		for _, column := range columns {
+			record = append(record, row.value(column))
+		}
+		if err = w.Write(record); err != nil {
+			return nil, fmt.Errorf("%s: %w", opExportParticipants, err)
+		}
+	}
+
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportParticipants, err)
+	}
+
+	s.log.InfoContext(ctx, "participants exported", slog.String("event_id", eventID), slog.Int("count", len(registrants)), slog.String("operation", opExportParticipants))
+	return buf.Bytes(), nil
+}
+
+// validateColumns проверяет, что все запрошенные колонки поддерживаются
+func validateColumns(columns []string) ([]string, error) {
+	if len(columns) == 0 {
+		return models.ParticipantColumns, nil
+	}
+
+	allowed := make(map[string]struct{}, len(models.ParticipantColumns))
+	for _, c := range models.ParticipantColumns {
+		allowed[c] = struct{}{}
+	}
+
+	for _, c := range columns {
+		if _, ok := allowed[c]; !ok {
+			return nil, fmt.Errorf("%w: %q", models.ErrUnknownColumn, c)
+		}
+	}
+	return columns, nil
+}
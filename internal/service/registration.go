@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opReconcileRegistrations = "service.ReconcileRegistrations"
+)
+
+// RegistrationKeeper определяет методы для хранения локальной копии регистраций
+type RegistrationKeeper interface {
+	UpsertRegistration(ctx context.Context, reg models.Registration) error
+	GetRegistrations(ctx context.Context) ([]models.Registration, error)
+	GetUserRegistrations(ctx context.Context, chatID int64) ([]models.Registration, error)
+	SetRegistrationStatus(ctx context.Context, chatID int64, eventID string, status models.RegistrationStatus) error
+	GetEventRegistrants(ctx context.Context, eventID string) ([]models.Registrant, error)
+	SetTicketNonce(ctx context.Context, chatID int64, eventID, nonce string) error
+	GetTicketNonce(ctx context.Context, chatID int64, eventID string) (string, error)
+}
+
+const (
+	// reconcileAbsentRuns сколько сверок подряд событие должно отсутствовать в каталоге,
+	// чтобы регистрации на него были помечены отсутствующими
+	reconcileAbsentRuns = 3
+	// reconcileShrinkRatio доля размера каталога при прошлой сверке, меньше которой каталог считается неполным:
+	// резкое сокращение каталога скорее означает сбой микросервиса событий, чем отмену большинства событий
+	reconcileShrinkRatio = 0.5
+)
+
+// reconcileState состояние сверки регистраций между запусками. Хранится в памяти,
+// поэтому после перезапуска бота отсчёт отсутствующих событий начинается заново
+type reconcileState struct {
+	mu sync.Mutex
+	// catalogSize размер непустого каталога событий при прошлой сверке
+	catalogSize int
+	// absent сколько сверок подряд событие с активными регистрациями отсутствует в каталоге
+	absent map[string]int
+}
+
+// catalogComplete запоминает размер каталога и сообщает, можно ли по нему помечать регистрации отсутствующими.
+// Пустой каталог не запоминается, а резко сократившийся запоминается, чтобы со следующей сверки он считался обычным
+func (st *reconcileState) catalogComplete(size int) bool {
+	if size == 0 {
+		return false
+	}
+	prev := st.catalogSize
+	st.catalogSize = size
+	return float64(size) >= float64(prev)*reconcileShrinkRatio
+}
+
+// registrationKey ключ регистрации пользователя на событие
+type registrationKey struct {
+	chatID  int64
+	eventID string
+}
+
+// ReconcileRegistrations сверяет локальные регистрации с микросервисом событий и записывает расхождения в лог.
+// Регистрации на события, отсутствующие в каталоге reconcileAbsentRuns сверок подряд, помечаются отсутствующими;
+// пустой или резко сократившийся каталог для этого не используется. Если микросервис событий отдаёт списки участников,
+// недостающие регистрации добавляются, а лишние помечаются отсутствующими
+func (s *Service) ReconcileRegistrations(ctx context.Context) (models.RegistrationDrift, error) {
+	var drift models.RegistrationDrift
+
+	s.reconcile.mu.Lock()
+	defer s.reconcile.mu.Unlock()
+
+	events, err := s.eventReceiver.GetEvents(ctx)
+	if err != nil {
+		return drift, fmt.Errorf("%s: %w", opReconcileRegistrations, err)
+	}
+	regs, err := s.registrations.GetRegistrations(ctx)
+	if err != nil {
+		return drift, fmt.Errorf("%s: %w", opReconcileRegistrations, err)
+	}
+
+	known := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		known[e.GetId()] = struct{}{}
+	}
+	local := make(map[registrationKey]models.Registration, len(regs))
+	byEvent := make(map[string][]models.Registration)
+	for _, r := range regs {
+		local[registrationKey{chatID: r.ChatID, eventID: r.EventID}] = r
+		byEvent[r.EventID] = append(byEvent[r.EventID], r)
+	}
+
+	markMissing := func(r models.Registration, reason string) error {
+		s.log.WarnContext(ctx, "registration drift",
+			slog.Int64("chat_id", r.ChatID),
+			slog.String("event_id", r.EventID),
+			slog.String("reason", reason),
+			slog.String("operation", opReconcileRegistrations),
+		)
+		return s.registrations.SetRegistrationStatus(ctx, r.ChatID, r.EventID, models.RegistrationStatusMissing)
+	}
+
+	if s.reconcile.catalogComplete(len(events)) {
+		absent := make(map[string]int)
+		for _, r := range regs {
+			if _, ok := known[r.EventID]; ok || r.Status != models.RegistrationStatusActive {
+				continue
+			}
+			runs, counted := absent[r.EventID]
+			if !counted {
+				runs = s.reconcile.absent[r.EventID] + 1
+				absent[r.EventID] = runs
+			}
+			if runs < reconcileAbsentRuns {
+				drift.Absent++
+				continue
+			}
+			if err = markMissing(r, "event not found"); err != nil {
+				return drift, fmt.Errorf("%s: %w", opReconcileRegistrations, err)
+			}
+			drift.Orphaned++
+		}
+		s.reconcile.absent = absent
+	} else {
+		// Счётчики отсутствия не сбрасываются и не растут, пока каталогу нельзя доверять
+		drift.CatalogIncomplete = true
+		s.log.WarnContext(ctx, "event catalog is empty or shrank sharply, skipping orphaned registrations check",
+			slog.Int("events", len(events)), slog.Int("previous_events", s.reconcile.catalogSize),
+			slog.String("operation", opReconcileRegistrations))
+	}
+
+	drift.RegistrantsChecked = true
+	for _, e := range events {
+		registrants, err := s.eventReceiver.GetRegistrants(ctx, e.GetId())
+		if errors.Is(err, models.ErrNotSupported) {
+			// Без списков участников сверяется только наличие событий
+			drift.RegistrantsChecked = false
+			break
+		}
+		if err != nil {
+			return drift, fmt.Errorf("%s: %w", opReconcileRegistrations, err)
+		}
+
+		remote := make(map[registrationKey]models.Registrant, len(registrants))
+		for _, r := range registrants {
+			remote[registrationKey{chatID: r.ChatID, eventID: e.GetId()}] = r
+		}
+
+		for key, r := range remote {
+			reg, ok := local[key]
+			if ok && reg.Status == models.RegistrationStatusActive {
+				continue
+			}
+			createdAt := r.RegisteredAt
+			if createdAt.IsZero() {
+				createdAt = time.Now()
+			}
+			if err = s.registrations.UpsertRegistration(ctx, models.Registration{
+				ChatID:    key.chatID,
+				EventID:   key.eventID,
+				Source:    models.RegistrationSourceReconcile,
+				CreatedAt: createdAt,
+			}); err != nil {
+				return drift, fmt.Errorf("%s: %w", opReconcileRegistrations, err)
+			}
+			if ok {
+				drift.Restored++
+			} else {
+				drift.Added++
+			}
+		}
+
+		for _, reg := range byEvent[e.GetId()] {
+			if reg.Status != models.RegistrationStatusActive {
+				continue
+			}
+			if _, ok := remote[registrationKey{chatID: reg.ChatID, eventID: reg.EventID}]; ok {
+				continue
+			}
+			if err = markMissing(reg, "registrant not found"); err != nil {
+				return drift, fmt.Errorf("%s: %w", opReconcileRegistrations, err)
+			}
+			drift.Missing++
+		}
+	}
+
+	attrs := []any{
+		slog.Int("added", drift.Added),
+		slog.Int("missing", drift.Missing),
+		slog.Int("restored", drift.Restored),
+		slog.Int("orphaned", drift.Orphaned),
+		slog.Int("absent", drift.Absent),
+		slog.Bool("catalog_incomplete", drift.CatalogIncomplete),
+		slog.Bool("registrants_checked", drift.RegistrantsChecked),
+		slog.String("operation", opReconcileRegistrations),
+	}
+	if drift.Total() > 0 {
+		s.log.WarnContext(ctx, "registrations drift detected", attrs...)
+	} else {
+		s.log.InfoContext(ctx, "registrations are in sync", attrs...)
+	}
+	return drift, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// fakeCatalog каталог событий для тестов, списки участников не поддерживаются
+type fakeCatalog struct {
+	EventReceiver
+	events []*pb.Event
+}
+
+func (f *fakeCatalog) GetEvents(context.Context) ([]*pb.Event, error) {
+	return f.events, nil
+}
+
+func (f *fakeCatalog) GetRegistrants(context.Context, string) ([]models.Registrant, error) {
+	return nil, models.ErrNotSupported
+}
+
+// fakeRegistrationList локальные регистрации для тестов сверки, неиспользуемые методы не реализованы
+type fakeRegistrationList struct {
+	RegistrationKeeper
+	regs []models.Registration
+}
+
+func (f *fakeRegistrationList) GetRegistrations(context.Context) ([]models.Registration, error) {
+	return f.regs, nil
+}
+
+func (f *fakeRegistrationList) SetRegistrationStatus(_ context.Context, chatID int64, eventID string, status models.RegistrationStatus) error {
+	for i, r := range f.regs {
+		if r.ChatID == chatID && r.EventID == eventID {
+			f.regs[i].Status = status
+		}
+	}
+	return nil
+}
+
+func TestReconcileRegistrationsOrphans(t *testing.T) {
+	catalog := func(ids ...string) []*pb.Event {
+		events := make([]*pb.Event, 0, len(ids))
+		for _, id := range ids {
+			events = append(events, &pb.Event{Id: id})
+		}
+		return events
+	}
+	full := catalog("1", "2", "3", "4")
+
+	tests := []struct {
+		name string
+		// runs каталог при каждой сверке подряд
+		runs         [][]*pb.Event
+		wantOrphaned int
+		wantStatus   models.RegistrationStatus
+	}{
+		{
+			name:       "event present",
+			runs:       [][]*pb.Event{full, full, full},
+			wantStatus: models.RegistrationStatusActive,
+		},
+		{
+			name:       "absent fewer runs than required",
+			runs:       [][]*pb.Event{catalog("2", "3", "4"), catalog("2", "3", "4")},
+			wantStatus: models.RegistrationStatusActive,
+		},
+		{
+			name:         "absent required runs in a row",
+			runs:         [][]*pb.Event{catalog("2", "3", "4"), catalog("2", "3", "4"), catalog("2", "3", "4")},
+			wantOrphaned: 1,
+			wantStatus:   models.RegistrationStatusMissing,
+		},
+		{
+			name:       "reappeared event resets count",
+			runs:       [][]*pb.Event{catalog("2", "3", "4"), catalog("2", "3", "4"), full, catalog("2", "3", "4")},
+			wantStatus: models.RegistrationStatusActive,
+		},
+		{
+			name:       "empty catalog",
+			runs:       [][]*pb.Event{nil, nil, nil, nil},
+			wantStatus: models.RegistrationStatusActive,
+		},
+		{
+			name:       "catalog shrank sharply",
+			runs:       [][]*pb.Event{full, catalog("2"), full, catalog("2")},
+			wantStatus: models.RegistrationStatusActive,
+		},
+		{
+			name:         "shrunk catalog accepted after one run",
+			runs:         [][]*pb.Event{full, catalog("2"), catalog("2"), catalog("2"), catalog("2")},
+			wantOrphaned: 1,
+			wantStatus:   models.RegistrationStatusMissing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &fakeCatalog{}
+			registrations := &fakeRegistrationList{regs: []models.Registration{
+				{ChatID: 42, EventID: "1", Status: models.RegistrationStatusActive},
+			}}
+			s := NewService(slog.New(slog.NewTextHandler(io.Discard, nil)), Deps{
+				EventReceiver: events,
+				Registrations: registrations,
+			})
+
+			orphaned := 0
+			for i, run := range tt.runs {
+				events.events = run
+				drift, err := s.ReconcileRegistrations(context.Background())
+				if err != nil {
+					t.Fatalf("run %d: %v", i, err)
+				}
+				orphaned += drift.Orphaned
+			}
+
+			if orphaned != tt.wantOrphaned {
+				t.Errorf("orphaned = %d, want %d", orphaned, tt.wantOrphaned)
+			}
+			if got := registrations.regs[0].Status; got != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opSearchEvents = "service.SearchEvents"
+)
+
+// Параметры поиска
+const (
+	// trigramThreshold минимальное сходство слов по триграммам, при котором слово считается совпавшим
+	trigramThreshold = 0.45
+	// suggestionThreshold минимальное сходство, при котором событие предлагается как подсказка
+	suggestionThreshold = 0.2
+	// maxSuggestions максимальное количество подсказок
+	maxSuggestions = 3
+	// maxQueryLength максимальная длина поискового запроса в символах
+	maxQueryLength = 100
+)
+
+// Окончания, отбрасываемые при упрощённом стемминге, от длинных к коротким
+var (
+	ruEndings = []string{
+		"иями", "ями", "ами", "ией", "иях", "ого", "его", "ому", "ему", "ыми", "ими",
+		"ая", "яя", "ое", "ее", "ые", "ие", "ый", "ий", "ой", "ей", "ам", "ям", "ах", "ях",
+		"ом", "ем", "ов", "ев", "ию", "ия", "ья", "ье", "ть",
+		"а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+	}
+	enEndings = []string{"ings", "ing", "ies", "ed", "es", "s"}
+)
+
+// SearchEvents ищет события по ключевым словам в названии и описании без учёта регистра,
+// с упрощённым стеммингом для русского и английского языков и нечётким сравнением по триграммам
+func (s *Service) SearchEvents(ctx context.Context, query string) (models.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return models.SearchResult{}, fmt.Errorf("%s: query cannot be empty", opSearchEvents)
+	}
+	if utf8.RuneCountInString(query) > maxQueryLength {
+		query = string([]rune(query)[:maxQueryLength])
+	}
+
+	events, err := s.GetEvents(ctx)
+	if err != nil {
+		return models.SearchResult{}, fmt.Errorf("%s: %w", opSearchEvents, err)
+	}
+
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return models.SearchResult{}, nil
+	}
+
+	type scored struct {
+		event *pb.Event
+		score float64
+	}
+
+	var found, similar []scored
+	for _, e := range events {
+		words := tokenize(e.GetTitle() + " " + e.GetDescription())
+		score, matched := matchTerms(terms, words)
+		if matched {
+			found = append(found, scored{event: e, score: score})
+		} else if score >= suggestionThreshold {
+			similar = append(similar, scored{event: e, score: score})
+		}
+	}
+
+	byScore := func(items []scored) []*pb.Event {
+		sort.SliceStable(items, func(i, j int) bool { return items[i].score > items[j].score })
+		result := make([]*pb.Event, 0, len(items))
+		for _, item := range items {
+			result = append(result, item.event)
+		}
+		return result
+	}
+
+	result := models.SearchResult{Events: byScore(found)}
+	if len(result.Events) == 0 {
+		result.Suggestions = byScore(similar)
+		if len(result.Suggestions) > maxSuggestions {
+			result.Suggestions = result.Suggestions[:maxSuggestions]
+		}
+	}
+
+	s.log.InfoContext(ctx, "events searched", slog.Int("found", len(result.Events)), slog.Int("suggestions", len(result.Suggestions)), slog.String("operation", opSearchEvents))
+	return result, nil
+}
+
+// matchTerms сопоставляет слова запроса со словами события.
+// Возвращает среднее сходство и признак того, что совпали все слова запроса
+func matchTerms(terms, words []string) (float64, bool) {
+	total := 0.0
+	matched := true
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range words {
+			if strings.HasPrefix(word, term) || (strings.HasPrefix(term, word) && utf8.RuneCountInString(word) >= 3) {
+				best = 1
+				break
+			}
+			if sim := trigramSimilarity(term, word); sim > best {
+				best = sim
+			}
+		}
+		if best < trigramThreshold {
+			matched = false
+		}
+		total += best
+	}
+	return total / float64(len(terms)), matched
+}
+
+// tokenize разбивает текст на слова в нижнем регистре и приводит их к основе
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		words = append(words, stem(strings.ReplaceAll(f, "ё", "е")))
+	}
+	return words
+}
+
+// stem отбрасывает типичное окончание слова, оставляя основу не короче трёх символов
+func stem(word string) string {
+	endings := enEndings
+	if r, _ := utf8.DecodeRuneInString(word); unicode.Is(unicode.Cyrillic, r) {
+		endings = ruEndings
+	}
+
+	for _, ending := range endings {
+		if strings.HasSuffix(word, ending) && utf8.RuneCountInString(word)-utf8.RuneCountInString(ending) >= 3 {
+			return strings.TrimSuffix(word, ending)
+		}
+	}
+	return word
+}
+
+// trigramSimilarity вычисляет сходство слов как отношение общих триграмм к их объединению
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// trigrams возвращает множество триграмм слова, дополненного пробелами по краям
+func trigrams(word string) map[string]struct{} {
+	runes := []rune("  " + word + " ")
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = struct{}{}
+	}
+	return set
+}
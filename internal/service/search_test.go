@@ -0,0 +1,67 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "концерты", want: "концерт"},
+		{word: "концертов", want: "концерт"},
+		{word: "лекциями", want: "лекц"},
+		{word: "выставка", want: "выставк"},
+		{word: "кот", want: "кот"},
+		{word: "коты", want: "кот"},
+		{word: "мир", want: "мир"},
+		{word: "meetings", want: "meet"},
+		{word: "workshops", want: "workshop"},
+		{word: "bus", want: "bus"},
+		{word: "2026", want: "2026"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := stem(tt.word); got != tt.want {
+				t.Errorf("stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchTerms(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		text        string
+		wantMatched bool
+		wantScore   float64
+	}{
+		{name: "exact word", query: "концерт", text: "Джазовый концерт", wantMatched: true, wantScore: 1},
+		{name: "word form", query: "концерты", text: "Джазовый концерт", wantMatched: true, wantScore: 1},
+		{name: "prefix of word", query: "конц", text: "Джазовый концерт", wantMatched: true, wantScore: 1},
+		{name: "case and yo", query: "ЁЛКА", text: "Новогодняя елка", wantMatched: true, wantScore: 1},
+		{name: "typo", query: "концетр", text: "Джазовый концерт", wantMatched: true},
+		{name: "unrelated word", query: "футбол", text: "Джазовый концерт", wantMatched: false},
+		{name: "every term must match", query: "джаз футбол", text: "Джазовый концерт", wantMatched: false},
+		{name: "several terms", query: "джаз концерт", text: "Концерт: вечер джаза", wantMatched: true, wantScore: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, matched := matchTerms(tokenize(tt.query), tokenize(tt.text))
+			if matched != tt.wantMatched {
+				t.Errorf("matched = %v, want %v (score %.2f)", matched, tt.wantMatched, score)
+			}
+			if tt.wantScore != 0 && math.Abs(score-tt.wantScore) > 1e-9 {
+				t.Errorf("score = %.2f, want %.2f", score, tt.wantScore)
+			}
+			if score < 0 || score > 1 {
+				t.Errorf("score = %.2f, want within [0, 1]", score)
+			}
+		})
+	}
+}
@@ -5,16 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"time"
 
 	pb "github.com/Telegram-bot-for-register-on-events/shared-proto/pb/event"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
 )
 
 // Константы для описания операций
 const (
-	opSaveUserInfo = "service.SaveUserInfo"
-	opGetEvents    = "service.GetEvents"
-	opGetEvent     = "service.GetEvent"
-	opRegisterUser = "service.RegisterUser"
+	opSaveUserInfo     = "service.SaveUserInfo"
+	opSaveUsersInfo    = "service.SaveUsersInfo"
+	opGetEvents        = "service.GetEvents"
+	opGetEvent         = "service.GetEvent"
+	opRegisterUser     = "service.RegisterUser"
+	opUpdateUserStatus = "service.UpdateUserStatus"
+	opTouchUser        = "service.TouchUser"
+	opGetUserStats     = "service.GetUserStats"
 )
 
 // Service описывает сервисный слой микросервиса
@@ -23,66 +31,190 @@ type Service struct {
 	eventReceiver EventReceiver
 	userRegister  UserRegister
 	userSaver     UserSaver
+	userStatus    UserStatusKeeper
+	userData      UserDataKeeper
+	checkIns      CheckInSaver
+	tickets       TicketSigner
+	feedback      FeedbackKeeper
+	userSettings  UserSettingsKeeper
+	eventExtras   EventExtrasKeeper
+	registrations RegistrationKeeper
+	// outbox очередь заявок на регистрацию, nil - заявки не откладываются
+	outbox       RegistrationOutbox
+	outboxPolicy OutboxPolicy
+	// eventChanges источник изменений событий, nil - уведомления об изменениях отключены
+	eventChanges EventChangeSource
+	// notifications очередь рассылок уведомлений пользователям
+	notifications NotificationOutbox
+	// subscriptions подписки на анонсы новых событий
+	subscriptions SubscriptionKeeper
+
+	registrationRemover RegistrationRemover
+	// reconcile состояние сверки регистраций между запусками
+	reconcile reconcileState
+
+	// defaultLocation часовой пояс для пользователей, не указавших свой
+	defaultLocation *time.Location
+	// pastEventsGrace сколько времени после начала событие ещё показывается в списке предстоящих
+	pastEventsGrace time.Duration
+	// eventDuration предполагаемая длительность события, контракт микросервиса событий её не содержит
+	eventDuration time.Duration
 }
 
 // EventReceiver описывает методы для получения информации о событиях
 type EventReceiver interface {
 	GetEvents(ctx context.Context) ([]*pb.Event, error)
 	GetEvent(ctx context.Context, eventID string) (*pb.Event, error)
+	GetRegistrants(ctx context.Context, eventID string) ([]models.Registrant, error)
 }
 
 // UserRegister описывает метод для регистрации пользователя на конкретное событие
 type UserRegister interface {
-	RegisterUser(ctx context.Context, eventID string, chatID int64, username string) (bool, error)
+	RegisterUser(ctx context.Context, eventID string, chatID int64, username, idempotencyKey string) (bool, error)
 }
 
 // UserSaver определяет методы для сохранения информации о пользователе
 type UserSaver interface {
-	SaveUserInfo(ctx context.Context, chatID int64, username string) error
+	SaveUserInfo(ctx context.Context, profile models.UserProfile) error
+	SaveUsersInfo(ctx context.Context, profiles []models.UserProfile) error
+}
+
+// UserStatusKeeper определяет методы для отслеживания статуса и активности пользователя
+type UserStatusKeeper interface {
+	UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatus) error
+	TouchUser(ctx context.Context, chatID int64) error
+	GetUserStats(ctx context.Context) (map[models.UserStatus]int, error)
+}
+
+// Deps зависимости сервисного слоя
+type Deps struct {
+	EventReceiver       EventReceiver
+	UserRegister        UserRegister
+	RegistrationRemover RegistrationRemover
+	UserSaver           UserSaver
+	UserStatus          UserStatusKeeper
+	UserData            UserDataKeeper
+	CheckIns            CheckInSaver
+	// Tickets подпись билетов, nil - билеты отключены
+	Tickets       TicketSigner
+	Feedback      FeedbackKeeper
+	UserSettings  UserSettingsKeeper
+	EventExtras   EventExtrasKeeper
+	Registrations RegistrationKeeper
+	// Outbox очередь заявок на регистрацию, nil - заявки не откладываются
+	Outbox       RegistrationOutbox
+	OutboxPolicy OutboxPolicy
+	// EventChanges источник изменений событий, nil - уведомления об изменениях отключены
+	EventChanges  EventChangeSource
+	Notifications NotificationOutbox
+	Subscriptions SubscriptionKeeper
+
+	DefaultLocation *time.Location
+	PastEventsGrace time.Duration
+	EventDuration   time.Duration
 }
 
 // NewService конструктор для создания Service
-func NewService(log *slog.Logger, eventReceiver EventReceiver, userRegister UserRegister, userSaver UserSaver) *Service {
+func NewService(log *slog.Logger, deps Deps) *Service {
 	return &Service{
 		log:           log,
-		eventReceiver: eventReceiver,
-		userRegister:  userRegister,
-		userSaver:     userSaver,
+		eventReceiver: deps.EventReceiver,
+		userRegister:  deps.UserRegister,
+		userSaver:     deps.UserSaver,
+		userStatus:    deps.UserStatus,
+		userData:      deps.UserData,
+		checkIns:      deps.CheckIns,
+		tickets:       deps.Tickets,
+		feedback:      deps.Feedback,
+		userSettings:  deps.UserSettings,
+		eventExtras:   deps.EventExtras,
+		registrations: deps.Registrations,
+		outbox:        deps.Outbox,
+		outboxPolicy:  deps.OutboxPolicy,
+		eventChanges:  deps.EventChanges,
+		notifications: deps.Notifications,
+		subscriptions: deps.Subscriptions,
+
+		registrationRemover: deps.RegistrationRemover,
+
+		defaultLocation: deps.DefaultLocation,
+		pastEventsGrace: deps.PastEventsGrace,
+		eventDuration:   deps.EventDuration,
 	}
 }
 
 // SaveUserInfo проводит валидацию входных данных и передаёт их в слой взаимодействия с базой данных
-func (s *Service) SaveUserInfo(ctx context.Context, chatID int64, username string) error {
-	if err := validateChatID(chatID); err != nil {
-		s.log.Error("error", err.Error(), slog.String("operation", opRegisterUser))
+func (s *Service) SaveUserInfo(ctx context.Context, profile models.UserProfile) error {
+	if err := validateProfile(profile); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opSaveUserInfo))
 		return err
 	}
 
-	if err := validateUsername(username); err != nil {
-		s.log.Error("error", err.Error(), slog.String("operation", opRegisterUser))
-		return err
-	}
-
-	err := s.userSaver.SaveUserInfo(ctx, chatID, username)
+	err := s.userSaver.SaveUserInfo(ctx, profile)
 	if err != nil {
 		return fmt.Errorf("%s: %w", opSaveUserInfo, err)
 	}
 	return nil
 }
 
-// GetEvents отправляет данные для получения всех событий
+// SaveUsersInfo проводит валидацию профилей и сохраняет корректные из них одним пакетом
+func (s *Service) SaveUsersInfo(ctx context.Context, profiles []models.UserProfile) error {
+	valid := make([]models.UserProfile, 0, len(profiles))
+	for _, p := range profiles {
+		if err := validateProfile(p); err != nil {
+			s.log.WarnContext(ctx, "skipping invalid profile", slog.Int64("chat_id", p.ChatID), logger.Err(err), slog.String("operation", opSaveUsersInfo))
+			continue
+		}
+		valid = append(valid, p)
+	}
+
+	if err := s.userSaver.SaveUsersInfo(ctx, valid); err != nil {
+		return fmt.Errorf("%s: %w", opSaveUsersInfo, err)
+	}
+	return nil
+}
+
+// GetEvents отправляет данные для получения предстоящих событий: отбрасывает события,
+// начавшиеся раньше, чем pastEventsGrace назад, и сортирует остальные по времени начала
 func (s *Service) GetEvents(ctx context.Context) ([]*pb.Event, error) {
+	events, err := s.GetAllEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := time.Now().Add(-s.pastEventsGrace)
+	upcoming := make([]*pb.Event, 0, len(events))
+	for _, e := range events {
+		if e.GetStartsAt() != nil && e.GetStartsAt().AsTime().Before(threshold) {
+			continue
+		}
+		upcoming = append(upcoming, e)
+	}
+	return upcoming, nil
+}
+
+// GetAllEvents отправляет данные для получения всех событий, включая прошедшие, отсортированных по времени начала
+func (s *Service) GetAllEvents(ctx context.Context) ([]*pb.Event, error) {
 	events, err := s.eventReceiver.GetEvents(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", opGetEvents, err)
 	}
+
+	// События без времени начала оказываются в конце списка
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i].GetStartsAt(), events[j].GetStartsAt()
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.AsTime().Before(b.AsTime())
+	})
 	return events, nil
 }
 
 // GetEvent проводит валидацию входных данных и отправляет их для получения конкретного события
 func (s *Service) GetEvent(ctx context.Context, eventID string) (*pb.Event, error) {
 	if err := validateEventID(eventID); err != nil {
-		s.log.Error("error", err.Error(), slog.String("operation", opGetEvent))
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opGetEvent))
 		return nil, err
 	}
 
@@ -93,28 +225,121 @@ func (s *Service) GetEvent(ctx context.Context, eventID string) (*pb.Event, erro
 	return event, nil
 }
 
-// RegisterUser валидирует входные данные и отправляет их для регистрации пользователя на конкретное событие
-func (s *Service) RegisterUser(ctx context.Context, eventID string, chatID int64, username string) (bool, error) {
-	if err := validateEventID(eventID); err != nil {
-		s.log.Error("error", err.Error(), slog.String("operation", opRegisterUser))
-		return false, err
+// RegisterUser валидирует входные данные, отправляет их для регистрации пользователя на конкретное событие
+// и сохраняет локальную копию регистрации. IdempotencyKey передаётся микросервису событий, чтобы повторный запрос с тем же ключом не создавал вторую регистрацию.
+// Если микросервис событий недоступен, заявка сохраняется в очередь и отправляется позже задачей DeliverQueuedRegistrations
+func (s *Service) RegisterUser(ctx context.Context, req models.RegistrationRequest) (models.RegistrationOutcome, error) {
+	if err := validateEventID(req.EventID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opRegisterUser))
+		return "", err
+	}
+
+	if err := validateUsername(req.Username); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opRegisterUser))
+		return "", err
 	}
 
-	if err := validateUsername(username); err != nil {
-		s.log.Error("error", err.Error(), slog.String("operation", opRegisterUser))
-		return false, err
+	if err := validateChatID(req.ChatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opRegisterUser))
+		return "", err
 	}
 
+	if req.IdempotencyKey == "" {
+		// Ключ нужен очереди заявок, без него повторное нажатие кнопки не отличить от новой заявки
+		req.IdempotencyKey = fmt.Sprintf("register:%d:%s", req.ChatID, req.EventID)
+	}
+
+	result, registerErr := s.userRegister.RegisterUser(ctx, req.EventID, req.ChatID, req.Username, req.IdempotencyKey)
+
+	// Время запроса могло истечь, пока микросервис событий не отвечал, а результат всё равно нужно сохранить
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), saveRegistrationTimeout)
+	defer cancel()
+
+	switch {
+	case errors.Is(registerErr, models.ErrEventServiceUnavailable) && s.outbox != nil:
+		outcome, err := s.outbox.EnqueueRegistration(saveCtx, req)
+		if err != nil {
+			s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opRegisterUser))
+			return "", fmt.Errorf("%s: %w", opRegisterUser, err)
+		}
+		if outcome != models.RegistrationQueued {
+			// Заявка из того же сообщения ещё ожидает отправки или уже доставлена, тогда возвращается её результат
+			return outcome, nil
+		}
+		s.log.InfoContext(ctx, "registration queued until event service is available",
+			slog.String("event_id", req.EventID), slog.Int64("chat_id", req.ChatID), slog.String("operation", opRegisterUser))
+		return models.RegistrationQueued, nil
+	case errors.Is(registerErr, models.ErrAlreadyRegistered):
+		// Повторная регистрация тоже означает, что регистрация в микросервисе событий есть
+		s.saveLocalRegistration(saveCtx, req)
+		return models.RegistrationAlreadyExists, nil
+	case registerErr != nil && !errors.Is(registerErr, models.ErrRequestRejected):
+		return "", fmt.Errorf("%s: %w", opRegisterUser, registerErr)
+	case !result:
+		return models.RegistrationRejected, nil
+	}
+	s.saveLocalRegistration(saveCtx, req)
+	return models.RegistrationConfirmed, nil
+}
+
+// saveLocalRegistration сохраняет локальную копию регистрации, подтверждённой микросервисом событий.
+// Регистрация в микросервисе событий уже выполнена, а общей транзакции с ним нет, поэтому ошибка только записывается в лог.
+// Недостающую копию добавит сверка, но только если микросервис событий отдаёт списки участников; иначе до повторного
+// нажатия кнопки регистрации пользователь не получит билет и уведомления об изменениях события
+func (s *Service) saveLocalRegistration(ctx context.Context, req models.RegistrationRequest) {
+	reg := models.Registration{ChatID: req.ChatID, EventID: req.EventID, Source: models.RegistrationSourceBot, CreatedAt: time.Now()}
+	if err := s.registrations.UpsertRegistration(ctx, reg); err != nil {
+		s.log.ErrorContext(ctx, "saving local registration copy", logger.Err(err),
+			slog.String("event_id", req.EventID), slog.Int64("chat_id", req.ChatID), slog.String("operation", opRegisterUser))
+	}
+}
+
+// UpdateUserStatus проводит валидацию входных данных и обновляет статус пользователя
+func (s *Service) UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatus) error {
+	if err := validateChatID(chatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opUpdateUserStatus))
+		return err
+	}
+
+	if !status.IsValid() {
+		err := fmt.Errorf("unknown user status %q", status)
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opUpdateUserStatus))
+		return err
+	}
+
+	if err := s.userStatus.UpdateUserStatus(ctx, chatID, status); err != nil {
+		return fmt.Errorf("%s: %w", opUpdateUserStatus, err)
+	}
+	return nil
+}
+
+// TouchUser отмечает активность пользователя
+func (s *Service) TouchUser(ctx context.Context, chatID int64) error {
 	if err := validateChatID(chatID); err != nil {
-		s.log.Error("error", err.Error(), slog.String("operation", opRegisterUser))
-		return false, err
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opTouchUser))
+		return err
 	}
 
-	result, err := s.userRegister.RegisterUser(ctx, eventID, chatID, username)
+	if err := s.userStatus.TouchUser(ctx, chatID); err != nil {
+		return fmt.Errorf("%s: %w", opTouchUser, err)
+	}
+	return nil
+}
+
+// GetUserStats возвращает количество пользователей в разрезе статусов
+func (s *Service) GetUserStats(ctx context.Context) (map[models.UserStatus]int, error) {
+	stats, err := s.userStatus.GetUserStats(ctx)
 	if err != nil {
-		return false, fmt.Errorf("%s: %w", opRegisterUser, err)
+		return nil, fmt.Errorf("%s: %w", opGetUserStats, err)
+	}
+	return stats, nil
+}
+
+func validateProfile(profile models.UserProfile) error {
+	if err := validateChatID(profile.ChatID); err != nil {
+		return err
 	}
-	return result, nil
+	return validateUsername(profile.Username)
 }
 
 func validateUsername(username string) error {
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opToggleSubscription         = "service.ToggleSubscription"
+	opQueueNewEventAnnouncements = "service.QueueNewEventAnnouncements"
+)
+
+// Ограничения подписки
+const (
+	// maxSubscriptionKeywords максимальное количество ключевых слов подписки
+	maxSubscriptionKeywords = 10
+	// maxKeywordLength максимальная длина ключевого слова в символах
+	maxKeywordLength = 50
+)
+
+// SubscriptionKeeper определяет методы для хранения подписок на анонсы новых событий
+type SubscriptionKeeper interface {
+	GetSubscription(ctx context.Context, chatID int64) (models.Subscription, error)
+	SetSubscription(ctx context.Context, sub models.Subscription) error
+	GetSubscribers(ctx context.Context) ([]models.Subscription, error)
+	QueueEventAnnouncements(ctx context.Context, announcements []models.EventAnnouncement) (int, error)
+}
+
+// ToggleSubscription включает или выключает подписку на анонсы новых событий.
+// Без ключевых слов переключает подписку на все события, с ключевыми словами через запятую -
+// подписывает на события, в названии или описании которых встречается хотя бы одно из них
+func (s *Service) ToggleSubscription(ctx context.Context, chatID int64, keywords string) (models.Subscription, error) {
+	if err := validateChatID(chatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opToggleSubscription))
+		return models.Subscription{}, err
+	}
+
+	parsed, err := parseKeywords(keywords)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+
+	sub, err := s.subscriptions.GetSubscription(ctx, chatID)
+	if err != nil {
+		return models.Subscription{}, fmt.Errorf("%s: %w", opToggleSubscription, err)
+	}
+
+	// Новые ключевые слова всегда включают подписку, повторная команда без них - выключает
+	sub.Active = len(parsed) > 0 || !sub.Active
+	sub.Keywords = parsed
+	if err = s.subscriptions.SetSubscription(ctx, sub); err != nil {
+		return models.Subscription{}, fmt.Errorf("%s: %w", opToggleSubscription, err)
+	}
+	return sub, nil
+}
+
+// parseKeywords разбирает ключевые слова подписки, перечисленные через запятую
+func parseKeywords(text string) ([]string, error) {
+	var keywords []string
+	seen := make(map[string]struct{})
+	for _, k := range strings.Split(text, ",") {
+		k = strings.ToLower(strings.Join(strings.Fields(k), " "))
+		if k == "" {
+			continue
+		}
+		if utf8.RuneCountInString(k) > maxKeywordLength {
+			return nil, fmt.Errorf("%w: keyword %q is longer than %d characters", models.ErrInvalidKeywords, k, maxKeywordLength)
+		}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		keywords = append(keywords, k)
+	}
+	if len(keywords) > maxSubscriptionKeywords {
+		return nil, fmt.Errorf("%w: more than %d keywords", models.ErrInvalidKeywords, maxSubscriptionKeywords)
+	}
+	return keywords, nil
+}
+
+// QueueNewEventAnnouncements находит в каталоге ещё не анонсированные предстоящие события и ставит в очередь рассылок
+// анонсы для подписчиков, ключевым словам которых они подходят. Анонсы отправляются задачей рассылки
+func (s *Service) QueueNewEventAnnouncements(ctx context.Context) error {
+	events, err := s.eventReceiver.GetEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opQueueNewEventAnnouncements, err)
+	}
+
+	subs, err := s.subscriptions.GetSubscribers(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opQueueNewEventAnnouncements, err)
+	}
+
+	// Получатели подбираются для всех предстоящих событий: какие из них новые, хранилище определяет в той же транзакции,
+	// в которой ставит анонсы в очередь
+	now := time.Now()
+	announcements := make([]models.EventAnnouncement, 0, len(events))
+	for _, e := range events {
+		if e.GetStartsAt() != nil && e.GetStartsAt().AsTime().Before(now) {
+			continue
+		}
+
+		a := models.EventAnnouncement{EventID: e.GetId(), Title: e.GetTitle(), Description: e.GetDescription()}
+		if e.GetStartsAt() != nil {
+			a.StartsAt = e.GetStartsAt().AsTime()
+		}
+		for _, sub := range subs {
+			if sub.Matches(a.Title + "\n" + a.Description) {
+				a.ChatIDs = append(a.ChatIDs, sub.ChatID)
+			}
+		}
+		announcements = append(announcements, a)
+	}
+
+	queued, err := s.subscriptions.QueueEventAnnouncements(ctx, announcements)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opQueueNewEventAnnouncements, err)
+	}
+	if queued > 0 {
+		s.log.InfoContext(ctx, "new event announcements queued", slog.Int("events", queued), slog.String("operation", opQueueNewEventAnnouncements))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+func TestParseKeywords(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", text: "", want: nil},
+		{name: "only separators", text: " , ,, ", want: nil},
+		{name: "single", text: "Концерт", want: []string{"концерт"}},
+		{name: "several", text: "концерт, джаз ,Лекция", want: []string{"концерт", "джаз", "лекция"}},
+		{name: "inner spaces collapsed", text: "живая   музыка", want: []string{"живая музыка"}},
+		{name: "duplicates removed", text: "джаз, ДЖАЗ, джаз", want: []string{"джаз"}},
+		{name: "too long keyword", text: strings.Repeat("я", maxKeywordLength+1), wantErr: true},
+		{name: "too many keywords", text: distinctKeywords(maxSubscriptionKeywords + 1), wantErr: true},
+		{name: "keywords at limit", text: distinctKeywords(maxSubscriptionKeywords), want: strings.Split(distinctKeywords(maxSubscriptionKeywords), ",")},
+		{name: "limit after deduplication", text: strings.Repeat("джаз,", maxSubscriptionKeywords+5), want: []string{"джаз"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKeywords(tt.text)
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrInvalidKeywords) {
+					t.Fatalf("got error %v, want %v", err, models.ErrInvalidKeywords)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// distinctKeywords возвращает n разных ключевых слов через запятую
+func distinctKeywords(n int) string {
+	keywords := make([]string, 0, n)
+	for i := range n {
+		keywords = append(keywords, "слово"+strconv.Itoa(i))
+	}
+	return strings.Join(keywords, ",")
+}
+
+func TestSubscriptionMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		keywords []string
+		text     string
+		want     bool
+	}{
+		{name: "no keywords matches everything", text: "Лекция по истории", want: true},
+		{name: "keyword in title", keywords: []string{"джаз"}, text: "Джазовый вечер", want: true},
+		{name: "any keyword is enough", keywords: []string{"футбол", "лекция"}, text: "Лекция по истории", want: true},
+		{name: "phrase keyword", keywords: []string{"живая музыка"}, text: "Вечер. Живая музыка и танцы", want: true},
+		{name: "no keyword found", keywords: []string{"футбол"}, text: "Лекция по истории", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := models.Subscription{Active: true, Keywords: tt.keywords}
+			if got := sub.Matches(tt.text); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
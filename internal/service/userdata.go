@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opExportUserData      = "service.ExportUserData"
+	opRequestUserDeletion = "service.RequestUserDeletion"
+	opCancelUserDeletion  = "service.CancelUserDeletion"
+	opDeleteUserData      = "service.DeleteUserData"
+)
+
+// UserDataKeeper определяет методы для выгрузки и удаления персональных данных пользователя
+type UserDataKeeper interface {
+	GetUser(ctx context.Context, chatID int64) (*models.User, error)
+	GetUsers(ctx context.Context, chatIDs []int64) ([]models.User, error)
+	GetUserCheckIns(ctx context.Context, chatID int64) ([]models.UserCheckIn, error)
+	GetUserFeedback(ctx context.Context, chatID int64) ([]models.UserFeedback, error)
+	DeleteUser(ctx context.Context, chatID int64) error
+	SaveAuditRecord(ctx context.Context, chatID int64, action models.AuditAction) error
+}
+
+// RegistrationRemover описывает метод для удаления регистраций пользователя в микросервисе событий
+type RegistrationRemover interface {
+	DeleteUserRegistrations(ctx context.Context, chatID int64) error
+}
+
+// ExportUserData собирает все сохранённые данные пользователя в JSON и фиксирует запрос в журнале аудита
+func (s *Service) ExportUserData(ctx context.Context, chatID int64) ([]byte, error) {
+	if err := validateChatID(chatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opExportUserData))
+		return nil, err
+	}
+
+	if err := s.userData.SaveAuditRecord(ctx, chatID, models.AuditActionExport); err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportUserData, err)
+	}
+
+	user, err := s.userData.GetUser(ctx, chatID)
+	if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+		return nil, fmt.Errorf("%s: %w", opExportUserData, err)
+	}
+
+	registrations, err := s.registrations.GetUserRegistrations(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportUserData, err)
+	}
+
+	checkIns, err := s.userData.GetUserCheckIns(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportUserData, err)
+	}
+
+	feedback, err := s.userData.GetUserFeedback(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportUserData, err)
+	}
+
+	data, err := json.MarshalIndent(models.UserDataExport{
+		User:          user,
+		Registrations: registrations,
+		CheckIns:      checkIns,
+		Feedback:      feedback,
+		ExportedAt:    time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opExportUserData, err)
+	}
+	return data, nil
+}
+
+// RequestUserDeletion фиксирует в журнале аудита запрос пользователя на удаление данных
+func (s *Service) RequestUserDeletion(ctx context.Context, chatID int64) error {
+	return s.audit(ctx, chatID, models.AuditActionDeleteRequested, opRequestUserDeletion)
+}
+
+// CancelUserDeletion фиксирует в журнале аудита отмену удаления данных
+func (s *Service) CancelUserDeletion(ctx context.Context, chatID int64) error {
+	return s.audit(ctx, chatID, models.AuditActionDeleteCancelled, opCancelUserDeletion)
+}
+
+// DeleteUserData удаляет регистрации пользователя в микросервисе событий и его данные в базе данных.
+// Возвращает false, если микросервис событий не поддерживает удаление и регистрации в нём сохранились.
+// Сейчас это всегда так: контракт микросервиса событий не содержит удаления регистраций
+func (s *Service) DeleteUserData(ctx context.Context, chatID int64) (bool, error) {
+	if err := validateChatID(chatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opDeleteUserData))
+		return false, err
+	}
+
+	registrationsDeleted := true
+	if err := s.registrationRemover.DeleteUserRegistrations(ctx, chatID); err != nil {
+		if !errors.Is(err, models.ErrNotSupported) {
+			return false, fmt.Errorf("%s: %w", opDeleteUserData, err)
+		}
+		s.log.WarnContext(ctx, "registrations were not deleted in event service", slog.Int64("chat_id", chatID), slog.String("operation", opDeleteUserData))
+		registrationsDeleted = false
+	}
+
+	if err := s.userData.DeleteUser(ctx, chatID); err != nil {
+		return false, fmt.Errorf("%s: %w", opDeleteUserData, err)
+	}
+	return registrationsDeleted, nil
+}
+
+// audit проводит валидацию входных данных и записывает действие пользователя в журнал аудита
+func (s *Service) audit(ctx context.Context, chatID int64, action models.AuditAction, op string) error {
+	if err := validateChatID(chatID); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", op))
+		return err
+	}
+
+	if err := s.userData.SaveAuditRecord(ctx, chatID, action); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opGetEventExtras  = "repo.GetEventExtras"
+	opSaveEventExtras = "repo.SaveEventExtras"
+)
+
+// eventExtras описывает строку таблицы event_extras
+type eventExtras struct {
+	EventID   string    `db:"event_id"`
+	Photo     string    `db:"photo"`
+	Address   string    `db:"address"`
+	Latitude  *float64  `db:"latitude"`
+	Longitude *float64  `db:"longitude"`
+	URL       string    `db:"url"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// GetEventExtras метод для получения дополнительной информации о событии, при её отсутствии возвращает пустую
+func (s *Storage) GetEventExtras(ctx context.Context, eventID string) (models.EventExtras, error) {
+	var row eventExtras
+	err := s.DB.GetContext(ctx, &row,
+		"select event_id, photo, address, latitude, longitude, url, updated_at from event_extras where event_id = $1",
+		eventID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.EventExtras{EventID: eventID}, nil
+		}
+		return models.EventExtras{}, fmt.Errorf("%s: %w", opGetEventExtras, err)
+	}
+
+	return models.EventExtras{
+		EventID:   row.EventID,
+		Photo:     row.Photo,
+		Address:   row.Address,
+		Latitude:  row.Latitude,
+		Longitude: row.Longitude,
+		URL:       row.URL,
+	}, nil
+}
+
+// SaveEventExtras метод для сохранения дополнительной информации о событии
+func (s *Storage) SaveEventExtras(ctx context.Context, extras models.EventExtras) error {
+	_, err := s.DB.NamedExecContext(ctx,
+		`insert into event_extras (event_id, photo, address, latitude, longitude, url, updated_at)
+		values (:event_id, :photo, :address, :latitude, :longitude, :url, :updated_at)
+		on conflict (event_id) do update set
+			photo = excluded.photo,
+			address = excluded.address,
+			latitude = excluded.latitude,
+			longitude = excluded.longitude,
+			url = excluded.url,
+			updated_at = excluded.updated_at`,
+		eventExtras{
+			EventID:   extras.EventID,
+			Photo:     extras.Photo,
+			Address:   extras.Address,
+			Latitude:  extras.Latitude,
+			Longitude: extras.Longitude,
+			URL:       extras.URL,
+			UpdatedAt: time.Now(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSaveEventExtras, err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opQueueFeedbackSurvey = "repo.QueueFeedbackSurvey"
+	opSaveFeedbackRating  = "repo.SaveFeedbackRating"
+	opSaveFeedbackComment = "repo.SaveFeedbackComment"
+	opGetFeedbackSummary  = "repo.GetFeedbackSummary"
+	opGetUserFeedback     = "repo.GetUserFeedback"
+)
+
+// feedbackSummaryComments количество последних комментариев в сводке по событию
+const feedbackSummaryComments = 10
+
+// QueueFeedbackSurvey метод для отметки опроса по событию отправленным и постановки его в очередь рассылок.
+// Опрос получают посетившие событие пользователи, а если отметок о посещении нет (например, билеты отключены) -
+// все, у кого есть активная регистрация. Возвращает false, если опрос уже был поставлен в очередь ранее
+func (s *Storage) QueueFeedbackSurvey(ctx context.Context, survey models.FeedbackSurvey) (bool, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opQueueFeedbackSurvey, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	res, err := tx.ExecContext(ctx,
+		"insert into feedback_surveys (event_id, sent_at) values ($1, $2) on conflict (event_id) do nothing",
+		survey.EventID, now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opQueueFeedbackSurvey, err)
+	}
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", opQueueFeedbackSurvey, err)
+	}
+	if claimed == 0 {
+		return false, nil
+	}
+
+	var chatIDs []int64
+	if err = tx.SelectContext(ctx, &chatIDs,
+		"select c.chat_id from checkins c join users u on u.chat_id = c.chat_id where c.event_id = $1 and u.status = $2",
+		survey.EventID, models.UserStatusActive,
+	); err != nil {
+		return false, fmt.Errorf("%s: %w", opQueueFeedbackSurvey, err)
+	}
+	if len(chatIDs) == 0 {
+		if err = tx.SelectContext(ctx, &chatIDs,
+			"select r.chat_id from registrations r join users u on u.chat_id = r.chat_id where r.event_id = $1 and r.status = $2 and u.status = $3",
+			survey.EventID, models.RegistrationStatusActive, models.UserStatusActive,
+		); err != nil {
+			return false, fmt.Errorf("%s: %w", opQueueFeedbackSurvey, err)
+		}
+	}
+
+	if err = enqueueNotifications(ctx, tx, models.NotificationFeedbackSurvey, survey, chatIDs, now); err != nil {
+		return false, fmt.Errorf("%s: %w", opQueueFeedbackSurvey, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: %w", opQueueFeedbackSurvey, err)
+	}
+	return true, nil
+}
+
+// SaveFeedbackRating метод для сохранения оценки события, повторная оценка заменяет предыдущую
+func (s *Storage) SaveFeedbackRating(ctx context.Context, eventID string, chatID int64, rating int) error {
+	now := time.Now()
+	_, err := s.DB.ExecContext(ctx,
+		`insert into feedback (event_id, chat_id, rating, created_at, updated_at) values ($1, $2, $3, $4, $4)
+		on conflict (event_id, chat_id) do update set rating = excluded.rating, updated_at = excluded.updated_at`,
+		eventID, chatID, rating, now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSaveFeedbackRating, err)
+	}
+	return nil
+}
+
+// SaveFeedbackComment метод для сохранения комментария к уже поставленной оценке
+func (s *Storage) SaveFeedbackComment(ctx context.Context, eventID string, chatID int64, comment string) error {
+	_, err := s.DB.ExecContext(ctx,
+		"update feedback set comment = $1, updated_at = $2 where event_id = $3 and chat_id = $4",
+		comment, time.Now(), eventID, chatID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSaveFeedbackComment, err)
+	}
+	return nil
+}
+
+// GetFeedbackSummary метод для получения агрегированных отзывов о событии
+func (s *Storage) GetFeedbackSummary(ctx context.Context, eventID string) (models.FeedbackSummary, error) {
+	summary := models.FeedbackSummary{EventID: eventID}
+
+	var ratings []struct {
+		Rating int `db:"rating"`
+		Count  int `db:"count"`
+	}
+	if err := s.DB.SelectContext(ctx, &ratings,
+		"select rating, count(*) as count from feedback where event_id = $1 group by rating",
+		eventID,
+	); err != nil {
+		return summary, fmt.Errorf("%s: %w", opGetFeedbackSummary, err)
+	}
+
+	total := 0
+	for _, r := range ratings {
+		if r.Rating < 1 || r.Rating > 5 {
+			continue
+		}
+		summary.Ratings[r.Rating-1] = r.Count
+		summary.Count += r.Count
+		total += r.Rating * r.Count
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(total) / float64(summary.Count)
+	}
+
+	if err := s.DB.SelectContext(ctx, &summary.Comments,
+		"select comment from feedback where event_id = $1 and comment <> '' order by updated_at desc limit $2",
+		eventID, feedbackSummaryComments,
+	); err != nil {
+		return summary, fmt.Errorf("%s: %w", opGetFeedbackSummary, err)
+	}
+
+	return summary, nil
+}
+
+// GetUserFeedback метод для получения отзывов пользователя о событиях
+func (s *Storage) GetUserFeedback(ctx context.Context, chatID int64) ([]models.UserFeedback, error) {
+	var rows []struct {
+		EventID   string    `db:"event_id"`
+		Rating    int       `db:"rating"`
+		Comment   string    `db:"comment"`
+		CreatedAt time.Time `db:"created_at"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}
+	err := s.DB.SelectContext(ctx, &rows,
+		"select event_id, rating, comment, created_at, updated_at from feedback where chat_id = $1 order by created_at",
+		chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opGetUserFeedback, err)
+	}
+
+	feedback := make([]models.UserFeedback, 0, len(rows))
+	for _, r := range rows {
+		feedback = append(feedback, models.UserFeedback{
+			EventID:   r.EventID,
+			Rating:    r.Rating,
+			Comment:   r.Comment,
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		})
+	}
+	return feedback, nil
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/storage/postgres/migrations"
+	"github.com/pressly/goose/v3"
+)
+
+// Константы для описания операций
+const (
+	opMigrate            = "postgres.migrate"
+	opLockMigrations     = "postgres.lockMigrations"
+	opSchemaVersion      = "postgres.schemaVersion"
+	opCheckSchemaVersion = "postgres.checkSchemaVersion"
+)
+
+// migrationsLockID ключ advisory lock, под которым реплики бота применяют миграции по очереди
+const migrationsLockID int64 = 5_839_104_527
+
+var (
+	// ErrSchemaTooNew возвращается, если база данных мигрирована более новой версией бота
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+	// ErrMigrationsLocked возвращается, если блокировку миграций не удалось получить до истечения контекста
+	ErrMigrationsLocked = errors.New("migrations are locked by another process")
+)
+
+// schemaVersionQuery возвращает версию последней применённой миграции, 0 - миграции ещё не применялись
+const schemaVersionQuery = `select case when to_regclass('goose_db_version') is null then 0
+	else (select coalesce(max(version_id), 0) from goose_db_version where is_applied) end`
+
+// newMigrationsProvider создаёт goose-провайдер для встроенных миграций
+func newMigrationsProvider(s *Storage) (*goose.Provider, error) {
+	return goose.NewProvider(goose.DialectPostgres, s.DB.DB, migrations.FS)
+}
+
+// LockMigrations захватывает advisory lock миграций, ожидая его не дольше, чем позволяет контекст.
+// Возвращённая функция снимает блокировку и должна быть вызвана в любом случае
+func (s *Storage) LockMigrations(ctx context.Context) (func(), error) {
+	conn, err := s.DB.Conn(ctx)
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opLockMigrations))
+		return nil, fmt.Errorf("%s: %w", opLockMigrations, err)
+	}
+
+	s.log.InfoContext(ctx, "waiting for migrations lock", slog.String("operation", opLockMigrations))
+	if _, err = conn.ExecContext(ctx, `select pg_advisory_lock($1)`, migrationsLockID); err != nil {
+		_ = conn.Close()
+		// Драйвер отменяет запрос по истечении контекста, но не оборачивает ошибку контекста
+		if ctx.Err() != nil {
+			err = ErrMigrationsLocked
+		}
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opLockMigrations))
+		return nil, fmt.Errorf("%s: %w", opLockMigrations, err)
+	}
+
+	return func() {
+		// Блокировка снимается и при отменённом контексте, иначе другие процессы ждали бы закрытия соединения
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `select pg_advisory_unlock($1)`, migrationsLockID); err != nil {
+			s.log.ErrorContext(ctx, "releasing migrations lock", logger.Err(err), slog.String("operation", opLockMigrations))
+		}
+		_ = conn.Close()
+	}, nil
+}
+
+// Migrate применяет встроенные миграции. Реплики, запущенные одновременно, ждут друг друга на advisory lock,
+// время ожидания ограничено контекстом
+func (s *Storage) Migrate(ctx context.Context) error {
+	unlock, err := s.LockMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opMigrate, err)
+	}
+	defer unlock()
+
+	// Версия проверяется под блокировкой: пока мы ждали, схему могла обновить более новая реплика
+	if err = s.CheckSchemaVersion(ctx); err != nil {
+		return fmt.Errorf("%s: %w", opMigrate, err)
+	}
+
+	provider, err := newMigrationsProvider(s)
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opMigrate))
+		return fmt.Errorf("%s: %w", opMigrate, err)
+	}
+	results, err := provider.Up(ctx)
+	for _, r := range results {
+		s.log.InfoContext(ctx, "migration applied",
+			slog.Int64("version", r.Source.Version),
+			slog.Duration("duration", r.Duration),
+			slog.String("operation", opMigrate),
+		)
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opMigrate))
+		return fmt.Errorf("%s: %w", opMigrate, err)
+	}
+
+	s.log.InfoContext(ctx, "migrations complete", slog.Int("applied", len(results)), slog.String("operation", opMigrate))
+	return nil
+}
+
+// SchemaVersion возвращает версию последней применённой миграции, не создавая таблицу версий goose
+func (s *Storage) SchemaVersion(ctx context.Context) (int64, error) {
+	var version int64
+	if err := s.DB.GetContext(ctx, &version, schemaVersionQuery); err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opSchemaVersion))
+		return 0, fmt.Errorf("%s: %w", opSchemaVersion, err)
+	}
+	return version, nil
+}
+
+// SupportedSchemaVersion возвращает версию последней встроенной миграции
+func (s *Storage) SupportedSchemaVersion() (int64, error) {
+	provider, err := newMigrationsProvider(s)
+	if err != nil {
+		return 0, err
+	}
+	sources := provider.ListSources()
+	if len(sources) == 0 {
+		return 0, nil
+	}
+	return sources[len(sources)-1].Version, nil
+}
+
+// CheckSchemaVersion сравнивает версию схемы базы данных с последней встроенной миграцией.
+// Возвращает ErrSchemaTooNew, если схема новее; о неприменённых миграциях только предупреждает
+func (s *Storage) CheckSchemaVersion(ctx context.Context) error {
+	supported, err := s.SupportedSchemaVersion()
+	if err != nil {
+		s.log.ErrorContext(ctx, "operation failed", logger.Err(err), slog.String("operation", opCheckSchemaVersion))
+		return fmt.Errorf("%s: %w", opCheckSchemaVersion, err)
+	}
+
+	current, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opCheckSchemaVersion, err)
+	}
+
+	if err = checkVersions(current, supported); err != nil {
+		s.log.ErrorContext(ctx, "database schema is newer than supported",
+			slog.Int64("schema_version", current),
+			slog.Int64("supported_version", supported),
+			slog.String("operation", opCheckSchemaVersion),
+		)
+		return fmt.Errorf("%s: %w", opCheckSchemaVersion, err)
+	}
+	if current < supported {
+		s.log.WarnContext(ctx, "database schema has pending migrations",
+			slog.Int64("schema_version", current),
+			slog.Int64("supported_version", supported),
+			slog.String("operation", opCheckSchemaVersion),
+		)
+	}
+	return nil
+}
+
+// checkVersions возвращает ErrSchemaTooNew, если версия схемы current новее последней встроенной миграции supported
+func checkVersions(current, supported int64) error {
+	if current > supported {
+		return fmt.Errorf("schema version %d, supported %d: %w", current, supported, ErrSchemaTooNew)
+	}
+	return nil
+}
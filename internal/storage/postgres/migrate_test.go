@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/storage/postgres/migrations"
+	"github.com/jmoiron/sqlx"
+)
+
+// testDSNEnv переменная окружения со строкой подключения к тестовой базе данных.
+// Тесты, которым нужна база данных, без неё пропускаются
+const testDSNEnv = "TEST_POSTGRES_DSN"
+
+// discardLogger логгер, отбрасывающий все записи
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestStorage подключается к тестовой базе данных или пропускает тест
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	s, err := NewStorage(discardLogger(), "postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = s.DB.Close() })
+	return s
+}
+
+func TestCheckVersions(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   int64
+		supported int64
+		wantErr   error
+	}{
+		{name: "fresh database", current: 0, supported: 16},
+		{name: "pending migrations", current: 10, supported: 16},
+		{name: "up to date", current: 16, supported: 16},
+		{name: "newer schema", current: 17, supported: 16, wantErr: ErrSchemaTooNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkVersions(tt.current, tt.supported); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSupportedSchemaVersion(t *testing.T) {
+	// Провайдер миграций не обращается к базе данных, пока не выполняется команда, поэтому подключение не нужно
+	db, err := sqlx.Open("postgres", "postgres://localhost/unused")
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	var latest int64
+	for _, f := range files {
+		prefix, _, _ := strings.Cut(f, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			t.Fatalf("migration %q has no version: %v", f, err)
+		}
+		latest = max(latest, version)
+	}
+
+	got, err := (&Storage{DB: db, log: discardLogger()}).SupportedSchemaVersion()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != latest {
+		t.Errorf("supported version = %d, want %d", got, latest)
+	}
+}
+
+func TestLockMigrations(t *testing.T) {
+	first, second := newTestStorage(t), newTestStorage(t)
+
+	unlock, err := first.LockMigrations(context.Background())
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err = second.LockMigrations(ctx); !errors.Is(err, ErrMigrationsLocked) {
+		unlock()
+		t.Fatalf("got %v, want %v", err, ErrMigrationsLocked)
+	}
+
+	unlock()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	unlockSecond, err := second.LockMigrations(ctx)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	unlockSecond()
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	supported, err := s.SupportedSchemaVersion()
+	if err != nil {
+		t.Fatalf("supported version: %v", err)
+	}
+
+	// Имитируем миграцию, применённую более новой версией бота
+	newer := supported + 1
+	if _, err = s.DB.ExecContext(ctx,
+		"insert into goose_db_version (version_id, is_applied) values ($1, true)", newer,
+	); err != nil {
+		t.Fatalf("failed to fake newer migration: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = s.DB.ExecContext(context.Background(), "delete from goose_db_version where version_id = $1", newer)
+	})
+
+	if err = s.CheckSchemaVersion(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("check: got %v, want %v", err, ErrSchemaTooNew)
+	}
+	if err = s.Migrate(ctx); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("migrate: got %v, want %v", err, ErrSchemaTooNew)
+	}
+	if got, err := s.SchemaVersion(ctx); err != nil || got != newer {
+		t.Errorf("schema version = %d, %v, want %d", got, err, newer)
+	}
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS status       VARCHAR NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP;
+
+UPDATE users SET last_seen_at = created_at WHERE last_seen_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_status ON users (status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_status;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS last_seen_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS first_name    VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_name     VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS language_code VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS updated_at    TIMESTAMP;
+
+UPDATE users SET updated_at = created_at WHERE updated_at IS NULL;
+
+-- +goose Down
+ALTER TABLE users
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS language_code,
+    DROP COLUMN IF EXISTS last_name,
+    DROP COLUMN IF EXISTS first_name;
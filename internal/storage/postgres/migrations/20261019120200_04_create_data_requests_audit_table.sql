@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS data_requests_audit (
+    id          BIGSERIAL PRIMARY KEY,
+    chat_id     BIGINT NOT NULL,
+    action      VARCHAR NOT NULL,
+    created_at  TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_data_requests_audit_chat_id ON data_requests_audit (chat_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS data_requests_audit;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS checkins (
+    event_id        VARCHAR NOT NULL,
+    chat_id         BIGINT NOT NULL,
+    nonce           VARCHAR NOT NULL,
+    checked_in_by   BIGINT NOT NULL,
+    checked_in_at   TIMESTAMP NOT NULL,
+    PRIMARY KEY (event_id, chat_id)
+    );
+
+-- +goose Down
+DROP TABLE IF EXISTS checkins;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS feedback (
+    event_id    VARCHAR NOT NULL,
+    chat_id     BIGINT NOT NULL,
+    rating      SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment     TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (event_id, chat_id)
+    );
+
+CREATE TABLE IF NOT EXISTS feedback_surveys (
+    event_id    VARCHAR NOT NULL PRIMARY KEY,
+    sent_at     TIMESTAMP NOT NULL
+    );
+
+-- +goose Down
+DROP TABLE IF EXISTS feedback_surveys;
+DROP TABLE IF EXISTS feedback;
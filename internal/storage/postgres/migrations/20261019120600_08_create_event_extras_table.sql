@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS event_extras (
+    event_id    VARCHAR NOT NULL PRIMARY KEY,
+    photo       VARCHAR NOT NULL DEFAULT '',
+    address     VARCHAR NOT NULL DEFAULT '',
+    latitude    DOUBLE PRECISION,
+    longitude   DOUBLE PRECISION,
+    url         VARCHAR NOT NULL DEFAULT '',
+    updated_at  TIMESTAMP NOT NULL
+    );
+
+-- +goose Down
+DROP TABLE IF EXISTS event_extras;
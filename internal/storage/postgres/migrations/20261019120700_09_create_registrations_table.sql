@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS registrations (
+    chat_id     BIGINT NOT NULL,
+    event_id    VARCHAR NOT NULL,
+    status      VARCHAR NOT NULL DEFAULT 'active',
+    source      VARCHAR NOT NULL,
+    created_at  TIMESTAMP NOT NULL,
+    updated_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (chat_id, event_id)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_registrations_event_id ON registrations (event_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_registrations_event_id;
+DROP TABLE IF EXISTS registrations;
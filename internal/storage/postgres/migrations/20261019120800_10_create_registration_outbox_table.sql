@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS registration_outbox (
+    id               BIGSERIAL PRIMARY KEY,
+    idempotency_key  VARCHAR NOT NULL UNIQUE,
+    chat_id          BIGINT NOT NULL,
+    event_id         VARCHAR NOT NULL,
+    username         VARCHAR NOT NULL,
+    message_id       INTEGER NOT NULL DEFAULT 0,
+    status           VARCHAR NOT NULL DEFAULT 'pending',
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    last_error       VARCHAR NOT NULL DEFAULT '',
+    next_attempt_at  TIMESTAMP NOT NULL,
+    created_at       TIMESTAMP NOT NULL,
+    updated_at       TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_registration_outbox_pending ON registration_outbox (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_registration_outbox_pending;
+DROP TABLE IF EXISTS registration_outbox;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS event_snapshots (
+    event_id    VARCHAR PRIMARY KEY,
+    title       VARCHAR NOT NULL,
+    starts_at   TIMESTAMP,
+    status      VARCHAR NOT NULL DEFAULT 'scheduled',
+    updated_at  TIMESTAMP NOT NULL
+    );
+
+-- +goose Down
+DROP TABLE IF EXISTS event_snapshots;
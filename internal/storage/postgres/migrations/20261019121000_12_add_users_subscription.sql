@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS subscribed BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS subscription_keywords VARCHAR[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS announced_events (
+    event_id      VARCHAR PRIMARY KEY,
+    announced_at  TIMESTAMP NOT NULL
+    );
+
+-- +goose Down
+DROP TABLE IF EXISTS announced_events;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS subscription_keywords,
+    DROP COLUMN IF EXISTS subscribed;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id               BIGSERIAL PRIMARY KEY,
+    chat_id          BIGINT NOT NULL,
+    kind             VARCHAR NOT NULL,
+    payload          JSONB NOT NULL,
+    status           VARCHAR NOT NULL DEFAULT 'pending',
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    last_error       VARCHAR NOT NULL DEFAULT '',
+    next_attempt_at  TIMESTAMP NOT NULL,
+    created_at       TIMESTAMP NOT NULL,
+    updated_at       TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_pending ON notification_outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_chat_id ON notification_outbox (chat_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notification_outbox_chat_id;
+DROP INDEX IF EXISTS idx_notification_outbox_pending;
+DROP TABLE IF EXISTS notification_outbox;
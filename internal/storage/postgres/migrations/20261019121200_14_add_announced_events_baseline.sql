@@ -0,0 +1,9 @@
+-- +goose Up
+-- Строка с пустым event_id отмечает, что каталог событий уже запомнен и новые события нужно анонсировать.
+-- Если анонсы уже рассылались, каталог запомнен до этой миграции
+INSERT INTO announced_events (event_id, announced_at)
+SELECT '', now() WHERE EXISTS (SELECT 1 FROM announced_events)
+ON CONFLICT (event_id) DO NOTHING;
+
+-- +goose Down
+DELETE FROM announced_events WHERE event_id = '';
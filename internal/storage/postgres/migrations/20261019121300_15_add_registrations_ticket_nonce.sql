@@ -0,0 +1,6 @@
+-- +goose Up
+-- Nonce последнего выданного билета, пустой - билет выдан до появления колонки и принимается по одной подписи
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS ticket_nonce VARCHAR NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE registrations DROP COLUMN IF EXISTS ticket_nonce;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Результат доставленной заявки, который получает пользователь при повторной регистрации из того же сообщения.
+-- Пустой у заявок, доставленных до появления колонки
+ALTER TABLE registration_outbox ADD COLUMN IF NOT EXISTS outcome VARCHAR NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE registration_outbox DROP COLUMN IF EXISTS outcome;
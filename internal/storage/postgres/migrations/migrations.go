@@ -0,0 +1,9 @@
+// Package migrations содержит SQL-миграции базы данных, встроенные в исполняемые файлы
+package migrations
+
+import "embed"
+
+// FS встроенные файлы миграций goose
+//
+//go:embed *.sql
+var FS embed.FS
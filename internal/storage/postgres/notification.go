@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Константы для описания операций
+const (
+	opClaimNotifications   = "repo.ClaimNotifications"
+	opCompleteNotification = "repo.CompleteNotification"
+	opFailNotification     = "repo.FailNotification"
+	opEnqueueEventChange   = "repo.enqueueEventChange"
+	opEnqueueNotifications = "repo.enqueueNotifications"
+	opDecodeNotification   = "repo.decodeNotification"
+)
+
+// notification описывает строку таблицы notification_outbox
+type notification struct {
+	ID       int64                   `db:"id"`
+	ChatID   int64                   `db:"chat_id"`
+	Kind     models.NotificationKind `db:"kind"`
+	Payload  []byte                  `db:"payload"`
+	Attempts int                     `db:"attempts"`
+}
+
+// claimNotificationsQuery выбирает готовые к отправке уведомления пользователям со статусом $5 и откладывает
+// их следующую попытку на время аренды, так же как claimOutboxQuery для заявок на регистрацию
+const claimNotificationsQuery = `update notification_outbox set attempts = attempts + 1, next_attempt_at = $1, updated_at = $2
+where id in (
+	select n.id from notification_outbox n join users u on u.chat_id = n.chat_id
+	where n.status = $3 and n.next_attempt_at <= $2 and u.status = $5
+	order by n.id limit $4 for update of n skip locked
+)
+returning id, chat_id, kind, payload, attempts`
+
+// dropInactiveNotificationsQuery отмечает неотправленными уведомления пользователям, заблокировавшим бота
+// или удалившим аккаунт: после возвращения пользователя они уже устарели бы
+const dropInactiveNotificationsQuery = `update notification_outbox n set status = $1, last_error = $2, updated_at = $3
+from users u where u.chat_id = n.chat_id and n.status = $4 and u.status <> $5`
+
+// inactiveRecipientError причина, с которой отбрасываются уведомления неактивным пользователям
+const inactiveRecipientError = "recipient is not active"
+
+// enqueueEventChangeQuery ставит в очередь уведомления об изменении события всем зарегистрированным на него
+// пользователям, не заблокировавшим бота. Состояние регистрации не учитывается: сверка помечает регистрации
+// на отменённое событие отсутствующими, а их владельцы тоже должны узнать об отмене
+const enqueueEventChangeQuery = `insert into notification_outbox (chat_id, kind, payload, status, next_attempt_at, created_at, updated_at)
+select r.chat_id, $1, $2, $3, $4, $4, $4 from registrations r join users u on u.chat_id = r.chat_id
+where r.event_id = $5 and u.status = $6`
+
+// enqueueEventChange ставит в очередь в транзакции tx уведомления об изменении события и возвращает их количество
+func enqueueEventChange(ctx context.Context, tx *sqlx.Tx, change models.EventChange, now time.Time) (int64, error) {
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opEnqueueEventChange, err)
+	}
+
+	res, err := tx.ExecContext(ctx, enqueueEventChangeQuery,
+		models.NotificationEventChange, payload, models.NotificationStatusPending, now,
+		change.New.EventID, models.UserStatusActive,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opEnqueueEventChange, err)
+	}
+	return res.RowsAffected()
+}
+
+// enqueueNotificationsQuery ставит в очередь одно и то же уведомление каждому из получателей
+const enqueueNotificationsQuery = `insert into notification_outbox (chat_id, kind, payload, status, next_attempt_at, created_at, updated_at)
+select unnest($1::bigint[]), $2, $3, $4, $5, $5, $5`
+
+// enqueueNotifications ставит в очередь в транзакции tx уведомление вида kind с содержимым payload для получателей chatIDs
+func enqueueNotifications(ctx context.Context, tx *sqlx.Tx, kind models.NotificationKind, payload any, chatIDs []int64, now time.Time) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opEnqueueNotifications, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, enqueueNotificationsQuery,
+		pq.Array(chatIDs), kind, data, models.NotificationStatusPending, now,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opEnqueueNotifications, err)
+	}
+	return nil
+}
+
+// ClaimNotifications метод для получения не более limit уведомлений, ожидающих отправки активным пользователям.
+// Уведомления заблокировавшим бота пользователям отмечаются неотправленными. Каждое полученное уведомление
+// считается попыткой отправки и не выдаётся повторно в течение lease
+func (s *Storage) ClaimNotifications(ctx context.Context, limit int, lease time.Duration) ([]models.Notification, error) {
+	now := time.Now()
+	if _, err := s.DB.ExecContext(ctx, dropInactiveNotificationsQuery,
+		models.NotificationStatusFailed, inactiveRecipientError, now, models.NotificationStatusPending, models.UserStatusActive,
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", opClaimNotifications, err)
+	}
+
+	var rows []notification
+	if err := s.DB.SelectContext(ctx, &rows, claimNotificationsQuery,
+		now.Add(lease), now, models.NotificationStatusPending, limit, models.UserStatusActive,
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", opClaimNotifications, err)
+	}
+
+	notifications := make([]models.Notification, 0, len(rows))
+	for _, r := range rows {
+		n, err := r.toModel()
+		if err != nil {
+			// Уведомление, которое не удалось разобрать, не получится отправить и после повтора
+			if failErr := s.FailNotification(ctx, r.ID, err.Error()); failErr != nil {
+				return nil, fmt.Errorf("%s: %w", opClaimNotifications, failErr)
+			}
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+// CompleteNotification метод для отметки уведомления доставленным
+func (s *Storage) CompleteNotification(ctx context.Context, id int64) error {
+	if _, err := s.DB.ExecContext(ctx,
+		"update notification_outbox set status = $1, last_error = '', updated_at = $2 where id = $3",
+		models.NotificationStatusSent, time.Now(), id,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opCompleteNotification, err)
+	}
+	return nil
+}
+
+// FailNotification метод для отметки уведомления, которое не удалось доставить
+func (s *Storage) FailNotification(ctx context.Context, id int64, lastError string) error {
+	if _, err := s.DB.ExecContext(ctx,
+		"update notification_outbox set status = $1, last_error = $2, updated_at = $3 where id = $4",
+		models.NotificationStatusFailed, lastError, time.Now(), id,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opFailNotification, err)
+	}
+	return nil
+}
+
+// toModel преобразует строку таблицы notification_outbox в доменную модель
+func (n notification) toModel() (models.Notification, error) {
+	result := models.Notification{ID: n.ID, ChatID: n.ChatID, Kind: n.Kind, Attempts: n.Attempts}
+	switch n.Kind {
+	case models.NotificationEventChange:
+		result.Change = &models.EventChange{}
+		if err := json.Unmarshal(n.Payload, result.Change); err != nil {
+			return result, fmt.Errorf("%s: %w", opDecodeNotification, err)
+		}
+	case models.NotificationFeedbackSurvey:
+		result.Survey = &models.FeedbackSurvey{}
+		if err := json.Unmarshal(n.Payload, result.Survey); err != nil {
+			return result, fmt.Errorf("%s: %w", opDecodeNotification, err)
+		}
+	case models.NotificationEventAnnouncement:
+		result.Announcement = &models.EventAnnouncement{}
+		if err := json.Unmarshal(n.Payload, result.Announcement); err != nil {
+			return result, fmt.Errorf("%s: %w", opDecodeNotification, err)
+		}
+	default:
+		return result, fmt.Errorf("%s: unknown notification kind %q", opDecodeNotification, n.Kind)
+	}
+	return result, nil
+}
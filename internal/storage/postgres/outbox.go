@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opEnqueueRegistration        = "repo.EnqueueRegistration"
+	opClaimQueuedRegistrations   = "repo.ClaimQueuedRegistrations"
+	opCompleteQueuedRegistration = "repo.CompleteQueuedRegistration"
+	opRetryQueuedRegistration    = "repo.RetryQueuedRegistration"
+	opFailQueuedRegistration     = "repo.FailQueuedRegistration"
+)
+
+// outboxEntry описывает строку таблицы registration_outbox
+type outboxEntry struct {
+	ID             int64     `db:"id"`
+	IdempotencyKey string    `db:"idempotency_key"`
+	ChatID         int64     `db:"chat_id"`
+	EventID        string    `db:"event_id"`
+	Username       string    `db:"username"`
+	MessageID      int       `db:"message_id"`
+	Attempts       int       `db:"attempts"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// claimOutboxQuery выбирает готовые к отправке заявки и откладывает их следующую попытку на время аренды.
+// Если обработчик упадёт, не закончив отправку, заявка вернётся в работу после окончания аренды,
+// а skip locked не даёт двум репликам бота взять одну и ту же заявку
+const claimOutboxQuery = `update registration_outbox set attempts = attempts + 1, next_attempt_at = $1, updated_at = $2
+where id in (
+	select id from registration_outbox where status = $3 and next_attempt_at <= $2
+	order by id limit $4 for update skip locked
+)
+returning id, idempotency_key, chat_id, event_id, username, message_id, attempts, created_at`
+
+// enqueueRegistrationQuery добавляет заявку в очередь. Недоставленная заявка с тем же ключом идемпотентности
+// снова ставится в очередь: пользователь повторил регистрацию из того же сообщения, пока микросервис событий недоступен
+const enqueueRegistrationQuery = `insert into registration_outbox (idempotency_key, chat_id, event_id, username, message_id, status, next_attempt_at, created_at, updated_at)
+values ($1, $2, $3, $4, $5, $6, $7, $7, $7)
+on conflict (idempotency_key) do update set
+	status = excluded.status,
+	attempts = 0,
+	last_error = '',
+	next_attempt_at = excluded.next_attempt_at,
+	updated_at = excluded.updated_at
+where registration_outbox.status = $8`
+
+// EnqueueRegistration метод для сохранения заявки на регистрацию в очередь. Возвращает models.RegistrationQueued,
+// если заявка поставлена в очередь, models.RegistrationAlreadyQueued, если заявка с тем же ключом идемпотентности
+// уже ожидает отправки, и сохранённый результат, если она уже была доставлена
+func (s *Storage) EnqueueRegistration(ctx context.Context, req models.RegistrationRequest) (models.RegistrationOutcome, error) {
+	res, err := s.DB.ExecContext(ctx, enqueueRegistrationQuery,
+		req.IdempotencyKey, req.ChatID, req.EventID, req.Username, req.MessageID, models.OutboxStatusPending, time.Now(),
+		models.OutboxStatusFailed,
+	)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", opEnqueueRegistration, err)
+	}
+
+	queued, err := res.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", opEnqueueRegistration, err)
+	}
+	if queued > 0 {
+		return models.RegistrationQueued, nil
+	}
+
+	var existing struct {
+		Status  models.OutboxStatus        `db:"status"`
+		Outcome models.RegistrationOutcome `db:"outcome"`
+	}
+	if err = s.DB.GetContext(ctx, &existing,
+		"select status, outcome from registration_outbox where idempotency_key = $1", req.IdempotencyKey,
+	); err != nil {
+		return "", fmt.Errorf("%s: %w", opEnqueueRegistration, err)
+	}
+
+	switch {
+	case existing.Status != models.OutboxStatusDelivered:
+		return models.RegistrationAlreadyQueued, nil
+	case existing.Outcome == "":
+		// Заявка доставлена до того, как результат начал сохраняться; доставленная заявка означает регистрацию
+		return models.RegistrationAlreadyExists, nil
+	}
+	return existing.Outcome, nil
+}
+
+// ClaimQueuedRegistrations метод для получения не более limit заявок, ожидающих отправки.
+// Каждая полученная заявка считается попыткой отправки и не выдаётся повторно в течение lease
+func (s *Storage) ClaimQueuedRegistrations(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEntry, error) {
+	now := time.Now()
+	var rows []outboxEntry
+	if err := s.DB.SelectContext(ctx, &rows, claimOutboxQuery,
+		now.Add(lease), now, models.OutboxStatusPending, limit,
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", opClaimQueuedRegistrations, err)
+	}
+
+	entries := make([]models.OutboxEntry, 0, len(rows))
+	for _, r := range rows {
+		entries = append(entries, models.OutboxEntry{
+			ID: r.ID,
+			Request: models.RegistrationRequest{
+				EventID:        r.EventID,
+				ChatID:         r.ChatID,
+				Username:       r.Username,
+				IdempotencyKey: r.IdempotencyKey,
+				MessageID:      r.MessageID,
+			},
+			Attempts:  r.Attempts,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	return entries, nil
+}
+
+// CompleteQueuedRegistration метод для отметки заявки доставленной с результатом outcome.
+// В той же транзакции сохраняется локальная копия подтверждённой регистрации
+func (s *Storage) CompleteQueuedRegistration(ctx context.Context, id int64, reg models.Registration, outcome models.RegistrationOutcome) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opCompleteQueuedRegistration, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx,
+		"update registration_outbox set status = $1, outcome = $2, last_error = '', updated_at = $3 where id = $4",
+		models.OutboxStatusDelivered, outcome, time.Now(), id,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opCompleteQueuedRegistration, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, upsertRegistrationQuery,
+		reg.ChatID, reg.EventID, models.RegistrationStatusActive, reg.Source, reg.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opCompleteQueuedRegistration, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", opCompleteQueuedRegistration, err)
+	}
+	return nil
+}
+
+// RetryQueuedRegistration метод для переноса следующей попытки отправки заявки на nextAttemptAt
+func (s *Storage) RetryQueuedRegistration(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	if _, err := s.DB.ExecContext(ctx,
+		"update registration_outbox set next_attempt_at = $1, last_error = $2, updated_at = $3 where id = $4",
+		nextAttemptAt, lastError, time.Now(), id,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opRetryQueuedRegistration, err)
+	}
+	return nil
+}
+
+// FailQueuedRegistration метод для отметки заявки, которую не удалось доставить
+func (s *Storage) FailQueuedRegistration(ctx context.Context, id int64, lastError string) error {
+	if _, err := s.DB.ExecContext(ctx,
+		"update registration_outbox set status = $1, last_error = $2, updated_at = $3 where id = $4",
+		models.OutboxStatusFailed, lastError, time.Now(), id,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opFailQueuedRegistration, err)
+	}
+	return nil
+}
@@ -2,24 +2,87 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/logger"
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Константы для описания операций
 const (
-	opSaveUserInfo = "repo.SaveUserInfo"
+	opSaveUserInfo     = "repo.SaveUserInfo"
+	opSaveUsersInfo    = "repo.SaveUsersInfo"
+	opUpdateUserStatus = "repo.UpdateUserStatus"
+	opTouchUser        = "repo.TouchUser"
+	opGetUserStats     = "repo.GetUserStats"
+	opGetUser          = "repo.GetUser"
+	opGetUsers         = "repo.GetUsers"
+	opDeleteUser       = "repo.DeleteUser"
+	opSaveAuditRecord  = "repo.SaveAuditRecord"
+	opSaveCheckIn      = "repo.SaveCheckIn"
+	opGetUserCheckIns  = "repo.GetUserCheckIns"
+	opGetUserTimezone  = "repo.GetUserTimezone"
+	opSetUserTimezone  = "repo.SetUserTimezone"
 )
 
 // User описывает данные о пользователе, необходимые для сохранения
 type User struct {
-	ChatID    int64     `db:"chat_id"`
-	Username  string    `db:"username"`
-	CreatedAt time.Time `db:"created_at"`
+	ChatID       int64             `db:"chat_id"`
+	Username     string            `db:"username"`
+	FirstName    string            `db:"first_name"`
+	LastName     string            `db:"last_name"`
+	LanguageCode string            `db:"language_code"`
+	Timezone     string            `db:"timezone"`
+	CreatedAt    time.Time         `db:"created_at"`
+	UpdatedAt    time.Time         `db:"updated_at"`
+	Status       models.UserStatus `db:"status"`
+	LastSeenAt   time.Time         `db:"last_seen_at"`
+	Subscribed   bool              `db:"subscribed"`
+	Keywords     pq.StringArray    `db:"subscription_keywords"`
+}
+
+// upsertUserQuery добавляет пользователя или обновляет его профиль, если он уже существует
+const upsertUserQuery = `insert into users (chat_id, username, first_name, last_name, language_code, created_at, updated_at, status, last_seen_at)
+values (:chat_id, :username, :first_name, :last_name, :language_code, :created_at, :updated_at, :status, :last_seen_at)
+on conflict (chat_id) do update set
+	username = excluded.username,
+	first_name = excluded.first_name,
+	last_name = excluded.last_name,
+	language_code = excluded.language_code,
+	updated_at = excluded.updated_at,
+	status = excluded.status,
+	last_seen_at = excluded.last_seen_at`
+
+// updateUserProfileQuery обновляет профиль существующего пользователя.
+// Статус не меняется: профили сохраняются с задержкой и не должны перезаписывать отметку о блокировке бота
+const updateUserProfileQuery = `update users set
+	username = :username,
+	first_name = :first_name,
+	last_name = :last_name,
+	language_code = :language_code,
+	updated_at = :updated_at,
+	last_seen_at = :last_seen_at
+where chat_id = :chat_id`
+
+// newUser собирает строку таблицы users из профиля пользователя
+func newUser(profile models.UserProfile, now time.Time) User {
+	return User{
+		ChatID:       profile.ChatID,
+		Username:     profile.Username,
+		FirstName:    profile.FirstName,
+		LastName:     profile.LastName,
+		LanguageCode: profile.LanguageCode,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Status:       models.UserStatusActive,
+		LastSeenAt:   now,
+	}
 }
 
 // Storage описывает объект базы данных
@@ -37,14 +100,16 @@ const (
 // NewStorage устанавливает соединение с базой данных, конструктор для Storage
 func NewStorage(log *slog.Logger, driverName, dsn string) (*Storage, error) {
 	db, err := sqlx.Open(driverName, dsn)
+	// Текст ошибки разбора строки подключения может содержать пароль
+	err = logger.ScrubError(err)
 	if err != nil {
-		log.Error("error", err.Error(), slog.String("operation", opConnect))
+		log.Error("operation failed", logger.Err(err), slog.String("operation", opConnect))
 		return nil, fmt.Errorf("%s: %w", opConnect, err)
 	}
 
 	// Проверяем подключение к базе данных, в противном случае возвращаем ошибку
-	if err = db.Ping(); err != nil {
-		log.Error("error", err.Error(), slog.String("operation", opConnect))
+	if err = logger.ScrubError(db.Ping()); err != nil {
+		log.Error("operation failed", logger.Err(err), slog.String("operation", opConnect))
 		return nil, fmt.Errorf("%s: %w", opConnect, err)
 	}
 
@@ -58,25 +123,269 @@ func NewStorage(log *slog.Logger, driverName, dsn string) (*Storage, error) {
 func (s *Storage) Close() {
 	s.log.Info("close db connection..", slog.String("operation", opCloseConnection))
 	if err := s.DB.Close(); err != nil {
-		s.log.Error("closing database connection", slog.String("error", err.Error()))
+		s.log.Error("closing database connection", logger.Err(err))
 	}
 }
 
-// SaveUserInfo метод для сохранения информации в базе данных
-func (s *Storage) SaveUserInfo(ctx context.Context, chatID int64, username string) error {
+// SaveUserInfo метод для сохранения информации в базе данных, обновляет профиль уже существующего пользователя
+func (s *Storage) SaveUserInfo(ctx context.Context, profile models.UserProfile) error {
 	// Выполняем INSERT-запрос
-	_, err := s.DB.NamedExecContext(ctx,
-		"insert into users (chat_id, username, created_at) values (:chat_id, :username, :created_at) on conflict (chat_id) do nothing",
-		User{
-			ChatID:    chatID,
-			Username:  username,
-			CreatedAt: time.Now(),
-		},
+	_, err := s.DB.NamedExecContext(ctx, upsertUserQuery, newUser(profile, time.Now()))
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSaveUserInfo, err)
+	}
+
+	return nil
+}
+
+// SaveUsersInfo метод для пакетного обновления профилей уже существующих пользователей в одной транзакции,
+// новые пользователи не создаются, чтобы не восстанавливать удалённые по запросу /deleteme данные
+func (s *Storage) SaveUsersInfo(ctx context.Context, profiles []models.UserProfile) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSaveUsersInfo, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareNamedContext(ctx, updateUserProfileQuery)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSaveUsersInfo, err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	now := time.Now()
+	for _, p := range profiles {
+		if _, err = stmt.ExecContext(ctx, newUser(p, now)); err != nil {
+			return fmt.Errorf("%s: %w", opSaveUsersInfo, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", opSaveUsersInfo, err)
+	}
+	return nil
+}
+
+// UpdateUserStatus метод для обновления статуса пользователя
+func (s *Storage) UpdateUserStatus(ctx context.Context, chatID int64, status models.UserStatus) error {
+	_, err := s.DB.ExecContext(ctx, "update users set status = $1 where chat_id = $2", status, chatID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opUpdateUserStatus, err)
+	}
+	return nil
+}
+
+// TouchUser метод для обновления времени последней активности пользователя, помечает его активным
+func (s *Storage) TouchUser(ctx context.Context, chatID int64) error {
+	_, err := s.DB.ExecContext(ctx,
+		"update users set last_seen_at = $1, status = $2 where chat_id = $3",
+		time.Now(), models.UserStatusActive, chatID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opTouchUser, err)
+	}
+	return nil
+}
+
+// GetUserStats метод для получения количества пользователей в разрезе статусов
+func (s *Storage) GetUserStats(ctx context.Context) (map[models.UserStatus]int, error) {
+	var rows []struct {
+		Status models.UserStatus `db:"status"`
+		Count  int               `db:"count"`
+	}
+	if err := s.DB.SelectContext(ctx, &rows, "select status, count(*) as count from users group by status"); err != nil {
+		return nil, fmt.Errorf("%s: %w", opGetUserStats, err)
+	}
+
+	stats := make(map[models.UserStatus]int, len(rows))
+	for _, r := range rows {
+		stats[r.Status] = r.Count
+	}
+	return stats, nil
+}
+
+// GetUser метод для получения всех сохранённых данных о пользователе
+func (s *Storage) GetUser(ctx context.Context, chatID int64) (*models.User, error) {
+	var u User
+	err := s.DB.GetContext(ctx, &u,
+		"select chat_id, username, first_name, last_name, language_code, timezone, status, created_at, updated_at, last_seen_at, subscribed, subscription_keywords from users where chat_id = $1",
+		chatID,
 	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", opGetUser, err)
+	}
 
+	return u.toModel(), nil
+}
+
+// GetUsers метод для получения данных о нескольких пользователях по их chat_id
+func (s *Storage) GetUsers(ctx context.Context, chatIDs []int64) ([]models.User, error) {
+	var rows []User
+	err := s.DB.SelectContext(ctx, &rows,
+		"select chat_id, username, first_name, last_name, language_code, timezone, status, created_at, updated_at, last_seen_at, subscribed, subscription_keywords from users where chat_id = any($1)",
+		pq.Array(chatIDs),
+	)
 	if err != nil {
-		return fmt.Errorf("%s: %w", opSaveUserInfo, err)
+		return nil, fmt.Errorf("%s: %w", opGetUsers, err)
+	}
+
+	users := make([]models.User, 0, len(rows))
+	for _, u := range rows {
+		users = append(users, *u.toModel())
+	}
+	return users, nil
+}
+
+// toModel преобразует строку таблицы users в доменную модель
+func (u User) toModel() *models.User {
+	return &models.User{
+		ChatID:       u.ChatID,
+		Username:     u.Username,
+		FirstName:    u.FirstName,
+		LastName:     u.LastName,
+		LanguageCode: u.LanguageCode,
+		Timezone:     u.Timezone,
+		Status:       u.Status,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
+		LastSeenAt:   u.LastSeenAt,
+
+		Subscribed:           u.Subscribed,
+		SubscriptionKeywords: u.Keywords,
+	}
+}
+
+// userDataTables таблицы с данными пользователя, которые удаляются по запросу /deleteme
+var userDataTables = []string{"users", "registrations", "registration_outbox", "checkins", "feedback", "notification_outbox"}
+
+// DeleteUser метод для удаления всех данных пользователя: профиля, регистраций, заявок на регистрацию,
+// отметок о посещении, отзывов и неотправленных уведомлений. В той же транзакции фиксирует удаление в журнале аудита
+func (s *Storage) DeleteUser(ctx context.Context, chatID int64) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opDeleteUser, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range userDataTables {
+		if _, err = tx.ExecContext(ctx, "delete from "+table+" where chat_id = $1", chatID); err != nil {
+			return fmt.Errorf("%s: %w", opDeleteUser, err)
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"insert into data_requests_audit (chat_id, action, created_at) values ($1, $2, $3)",
+		chatID, models.AuditActionDeleteCompleted, time.Now(),
+	); err != nil {
+		return fmt.Errorf("%s: %w", opDeleteUser, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", opDeleteUser, err)
+	}
+	return nil
+}
+
+// SaveAuditRecord метод для записи запроса пользователя в журнал аудита
+func (s *Storage) SaveAuditRecord(ctx context.Context, chatID int64, action models.AuditAction) error {
+	_, err := s.DB.ExecContext(ctx,
+		"insert into data_requests_audit (chat_id, action, created_at) values ($1, $2, $3)",
+		chatID, action, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSaveAuditRecord, err)
+	}
+	return nil
+}
+
+// SaveCheckIn метод для отметки посещения события участником.
+// Если участник уже отмечен, возвращает первую отметку с признаком Duplicate
+func (s *Storage) SaveCheckIn(ctx context.Context, checkIn models.CheckIn) (models.CheckIn, error) {
+	res, err := s.DB.ExecContext(ctx,
+		"insert into checkins (event_id, chat_id, nonce, checked_in_by, checked_in_at) values ($1, $2, $3, $4, $5) on conflict (event_id, chat_id) do nothing",
+		checkIn.EventID, checkIn.ChatID, checkIn.Nonce, checkIn.CheckedInBy, checkIn.CheckedInAt,
+	)
+	if err != nil {
+		return models.CheckIn{}, fmt.Errorf("%s: %w", opSaveCheckIn, err)
 	}
 
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return models.CheckIn{}, fmt.Errorf("%s: %w", opSaveCheckIn, err)
+	}
+	if inserted > 0 {
+		return checkIn, nil
+	}
+
+	var existing struct {
+		Nonce       string    `db:"nonce"`
+		CheckedInBy int64     `db:"checked_in_by"`
+		CheckedInAt time.Time `db:"checked_in_at"`
+	}
+	err = s.DB.GetContext(ctx, &existing,
+		"select nonce, checked_in_by, checked_in_at from checkins where event_id = $1 and chat_id = $2",
+		checkIn.EventID, checkIn.ChatID,
+	)
+	if err != nil {
+		return models.CheckIn{}, fmt.Errorf("%s: %w", opSaveCheckIn, err)
+	}
+
+	checkIn.Nonce = existing.Nonce
+	checkIn.CheckedInBy = existing.CheckedInBy
+	checkIn.CheckedInAt = existing.CheckedInAt
+	checkIn.Duplicate = true
+	return checkIn, nil
+}
+
+// GetUserCheckIns метод для получения отметок о посещении событий пользователем
+func (s *Storage) GetUserCheckIns(ctx context.Context, chatID int64) ([]models.UserCheckIn, error) {
+	var rows []struct {
+		EventID     string    `db:"event_id"`
+		CheckedInAt time.Time `db:"checked_in_at"`
+	}
+	err := s.DB.SelectContext(ctx, &rows,
+		"select event_id, checked_in_at from checkins where chat_id = $1 order by checked_in_at",
+		chatID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opGetUserCheckIns, err)
+	}
+
+	checkIns := make([]models.UserCheckIn, 0, len(rows))
+	for _, r := range rows {
+		checkIns = append(checkIns, models.UserCheckIn{EventID: r.EventID, CheckedInAt: r.CheckedInAt})
+	}
+	return checkIns, nil
+}
+
+// GetUserTimezone метод для получения часового пояса пользователя, пустая строка - не задан
+func (s *Storage) GetUserTimezone(ctx context.Context, chatID int64) (string, error) {
+	var timezone string
+	err := s.DB.GetContext(ctx, &timezone, "select timezone from users where chat_id = $1", chatID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", models.ErrUserNotFound
+		}
+		return "", fmt.Errorf("%s: %w", opGetUserTimezone, err)
+	}
+	return timezone, nil
+}
+
+// SetUserTimezone метод для сохранения часового пояса пользователя
+func (s *Storage) SetUserTimezone(ctx context.Context, chatID int64, timezone string) error {
+	res, err := s.DB.ExecContext(ctx, "update users set timezone = $1, updated_at = $2 where chat_id = $3", timezone, time.Now(), chatID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSetUserTimezone, err)
+	}
+	if updated, err := res.RowsAffected(); err == nil && updated == 0 {
+		return models.ErrUserNotFound
+	}
 	return nil
 }
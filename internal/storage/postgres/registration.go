@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opUpsertRegistration    = "repo.UpsertRegistration"
+	opGetRegistrations      = "repo.GetRegistrations"
+	opGetUserRegistrations  = "repo.GetUserRegistrations"
+	opSetRegistrationStatus = "repo.SetRegistrationStatus"
+	opGetEventRegistrants   = "repo.GetEventRegistrants"
+	opSetTicketNonce        = "repo.SetTicketNonce"
+	opGetTicketNonce        = "repo.GetTicketNonce"
+)
+
+// registration описывает строку таблицы registrations
+type registration struct {
+	ChatID    int64                     `db:"chat_id"`
+	EventID   string                    `db:"event_id"`
+	Status    models.RegistrationStatus `db:"status"`
+	Source    models.RegistrationSource `db:"source"`
+	CreatedAt time.Time                 `db:"created_at"`
+}
+
+// upsertRegistrationQuery добавляет регистрацию или делает существующую снова активной
+const upsertRegistrationQuery = `insert into registrations (chat_id, event_id, status, source, created_at, updated_at)
+values ($1, $2, $3, $4, $5, $5)
+on conflict (chat_id, event_id) do update set status = excluded.status, updated_at = excluded.updated_at`
+
+// UpsertRegistration метод для добавления активной регистрации без подтверждения, например найденной при сверке
+func (s *Storage) UpsertRegistration(ctx context.Context, reg models.Registration) error {
+	if _, err := s.DB.ExecContext(ctx, upsertRegistrationQuery,
+		reg.ChatID, reg.EventID, models.RegistrationStatusActive, reg.Source, reg.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opUpsertRegistration, err)
+	}
+	return nil
+}
+
+// GetRegistrations метод для получения всех локальных регистраций
+func (s *Storage) GetRegistrations(ctx context.Context) ([]models.Registration, error) {
+	var rows []registration
+	if err := s.DB.SelectContext(ctx, &rows,
+		"select chat_id, event_id, status, source, created_at from registrations",
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", opGetRegistrations, err)
+	}
+	return toRegistrations(rows), nil
+}
+
+// GetUserRegistrations метод для получения регистраций пользователя
+func (s *Storage) GetUserRegistrations(ctx context.Context, chatID int64) ([]models.Registration, error) {
+	var rows []registration
+	if err := s.DB.SelectContext(ctx, &rows,
+		"select chat_id, event_id, status, source, created_at from registrations where chat_id = $1 order by created_at",
+		chatID,
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", opGetUserRegistrations, err)
+	}
+	return toRegistrations(rows), nil
+}
+
+// SetRegistrationStatus метод для изменения состояния регистрации
+func (s *Storage) SetRegistrationStatus(ctx context.Context, chatID int64, eventID string, status models.RegistrationStatus) error {
+	if _, err := s.DB.ExecContext(ctx,
+		"update registrations set status = $1, updated_at = $2 where chat_id = $3 and event_id = $4",
+		status, time.Now(), chatID, eventID,
+	); err != nil {
+		return fmt.Errorf("%s: %w", opSetRegistrationStatus, err)
+	}
+	return nil
+}
+
+// GetEventRegistrants метод для получения участников события по активным локальным регистрациям
+func (s *Storage) GetEventRegistrants(ctx context.Context, eventID string) ([]models.Registrant, error) {
+	var rows []struct {
+		ChatID    int64          `db:"chat_id"`
+		Username  sql.NullString `db:"username"`
+		CreatedAt time.Time      `db:"created_at"`
+	}
+	if err := s.DB.SelectContext(ctx, &rows,
+		`select r.chat_id, u.username, r.created_at from registrations r
+		left join users u on u.chat_id = r.chat_id
+		where r.event_id = $1 and r.status = $2 order by r.created_at`,
+		eventID, models.RegistrationStatusActive,
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", opGetEventRegistrants, err)
+	}
+
+	registrants := make([]models.Registrant, 0, len(rows))
+	for _, r := range rows {
+		registrants = append(registrants, models.Registrant{ChatID: r.ChatID, Username: r.Username.String, RegisteredAt: r.CreatedAt})
+	}
+	return registrants, nil
+}
+
+// SetTicketNonce метод для сохранения nonce выданного билета в активной регистрации.
+// Билет, выданный ранее, после этого не принимается. Возвращает models.ErrRegistrationNotFound,
+// если активной регистрации нет
+func (s *Storage) SetTicketNonce(ctx context.Context, chatID int64, eventID, nonce string) error {
+	res, err := s.DB.ExecContext(ctx,
+		"update registrations set ticket_nonce = $1, updated_at = $2 where chat_id = $3 and event_id = $4 and status = $5",
+		nonce, time.Now(), chatID, eventID, models.RegistrationStatusActive,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSetTicketNonce, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSetTicketNonce, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", opSetTicketNonce, models.ErrRegistrationNotFound)
+	}
+	return nil
+}
+
+// GetTicketNonce метод для получения nonce последнего билета, выданного по активной регистрации.
+// Возвращает models.ErrRegistrationNotFound, если активной регистрации нет
+func (s *Storage) GetTicketNonce(ctx context.Context, chatID int64, eventID string) (string, error) {
+	var nonce string
+	err := s.DB.GetContext(ctx, &nonce,
+		"select ticket_nonce from registrations where chat_id = $1 and event_id = $2 and status = $3",
+		chatID, eventID, models.RegistrationStatusActive,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", opGetTicketNonce, models.ErrRegistrationNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", opGetTicketNonce, err)
+	}
+	return nonce, nil
+}
+
+// toRegistrations преобразует строки таблицы registrations в доменные модели
+func toRegistrations(rows []registration) []models.Registration {
+	regs := make([]models.Registration, 0, len(rows))
+	for _, r := range rows {
+		regs = append(regs, models.Registration{
+			ChatID:    r.ChatID,
+			EventID:   r.EventID,
+			Status:    r.Status,
+			Source:    r.Source,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	return regs
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+)
+
+// Константы для описания операций
+const (
+	opUpdateEventSnapshots = "repo.UpdateEventSnapshots"
+)
+
+// eventSnapshotsLockID ключ advisory lock, под которым реплики бота по очереди обновляют снимок каталога событий
+const eventSnapshotsLockID int64 = 5_839_104_528
+
+// eventSnapshot описывает строку таблицы event_snapshots
+type eventSnapshot struct {
+	EventID  string             `db:"event_id"`
+	Title    string             `db:"title"`
+	StartsAt sql.NullTime       `db:"starts_at"`
+	Status   models.EventStatus `db:"status"`
+}
+
+// UpdateEventSnapshots метод для замены снимка каталога событий результатом diff.
+// Снимок читается и записывается в одной транзакции под advisory lock: реплика, дождавшаяся блокировки,
+// сравнивает каталог уже с обновлённым снимком и не находит тех же изменений повторно.
+// В той же транзакции уведомления о найденных изменениях ставятся в очередь рассылок, поэтому они не теряются,
+// даже если рассылка прервётся. Ошибка diff не оборачивается и откатывает транзакцию
+func (s *Storage) UpdateEventSnapshots(ctx context.Context, diff func(prev []models.EventSnapshot) ([]models.EventSnapshot, []models.EventChange, error)) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opUpdateEventSnapshots, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, "select pg_advisory_xact_lock($1)", eventSnapshotsLockID); err != nil {
+		return fmt.Errorf("%s: %w", opUpdateEventSnapshots, err)
+	}
+
+	var rows []eventSnapshot
+	if err = tx.SelectContext(ctx, &rows, "select event_id, title, starts_at, status from event_snapshots"); err != nil {
+		return fmt.Errorf("%s: %w", opUpdateEventSnapshots, err)
+	}
+	prev := make([]models.EventSnapshot, 0, len(rows))
+	for _, r := range rows {
+		prev = append(prev, models.EventSnapshot{EventID: r.EventID, Title: r.Title, StartsAt: r.StartsAt.Time, Status: r.Status})
+	}
+
+	next, changes, err := diff(prev)
+	if err != nil {
+		return err
+	}
+
+	// Каталог событий невелик, поэтому снимок проще заменить целиком
+	if _, err = tx.ExecContext(ctx, "delete from event_snapshots"); err != nil {
+		return fmt.Errorf("%s: %w", opUpdateEventSnapshots, err)
+	}
+	now := time.Now()
+	for _, snapshot := range next {
+		if _, err = tx.ExecContext(ctx,
+			"insert into event_snapshots (event_id, title, starts_at, status, updated_at) values ($1, $2, $3, $4, $5)",
+			snapshot.EventID, snapshot.Title, sql.NullTime{Time: snapshot.StartsAt, Valid: !snapshot.StartsAt.IsZero()}, snapshot.Status, now,
+		); err != nil {
+			return fmt.Errorf("%s: %w", opUpdateEventSnapshots, err)
+		}
+	}
+
+	for _, change := range changes {
+		if _, err = enqueueEventChange(ctx, tx, change, now); err != nil {
+			return fmt.Errorf("%s: %w", opUpdateEventSnapshots, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", opUpdateEventSnapshots, err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Telegram-bot-for-register-on-events/telegram-bot-service/internal/models"
+	"github.com/lib/pq"
+)
+
+// Константы для описания операций
+const (
+	opGetSubscription         = "repo.GetSubscription"
+	opSetSubscription         = "repo.SetSubscription"
+	opGetSubscribers          = "repo.GetSubscribers"
+	opQueueEventAnnouncements = "repo.QueueEventAnnouncements"
+)
+
+// subscription описывает подписку в строке таблицы users
+type subscription struct {
+	ChatID     int64          `db:"chat_id"`
+	Subscribed bool           `db:"subscribed"`
+	Keywords   pq.StringArray `db:"subscription_keywords"`
+}
+
+// toModel преобразует подписку из строки таблицы users в доменную модель
+func (s subscription) toModel() models.Subscription {
+	return models.Subscription{ChatID: s.ChatID, Active: s.Subscribed, Keywords: s.Keywords}
+}
+
+// GetSubscription метод для получения подписки пользователя на анонсы новых событий
+func (s *Storage) GetSubscription(ctx context.Context, chatID int64) (models.Subscription, error) {
+	var row subscription
+	err := s.DB.GetContext(ctx, &row, "select chat_id, subscribed, subscription_keywords from users where chat_id = $1", chatID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Subscription{}, models.ErrUserNotFound
+		}
+		return models.Subscription{}, fmt.Errorf("%s: %w", opGetSubscription, err)
+	}
+	return row.toModel(), nil
+}
+
+// SetSubscription метод для сохранения подписки пользователя на анонсы новых событий
+func (s *Storage) SetSubscription(ctx context.Context, sub models.Subscription) error {
+	keywords := sub.Keywords
+	if keywords == nil {
+		keywords = []string{}
+	}
+	res, err := s.DB.ExecContext(ctx,
+		"update users set subscribed = $1, subscription_keywords = $2, updated_at = $3 where chat_id = $4",
+		sub.Active, pq.StringArray(keywords), time.Now(), sub.ChatID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opSetSubscription, err)
+	}
+	if updated, err := res.RowsAffected(); err == nil && updated == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+// GetSubscribers метод для получения подписок пользователей, не заблокировавших бота
+func (s *Storage) GetSubscribers(ctx context.Context) ([]models.Subscription, error) {
+	var rows []subscription
+	if err := s.DB.SelectContext(ctx, &rows,
+		"select chat_id, subscribed, subscription_keywords from users where subscribed and status = $1",
+		models.UserStatusActive,
+	); err != nil {
+		return nil, fmt.Errorf("%s: %w", opGetSubscribers, err)
+	}
+
+	subs := make([]models.Subscription, 0, len(rows))
+	for _, r := range rows {
+		subs = append(subs, r.toModel())
+	}
+	return subs, nil
+}
+
+// announcementBaselineMarker строка таблицы announced_events, которая отмечает, что каталог событий уже запомнен.
+// Пустой event_id не совпадает ни с одним событием: микросервис событий не выдаёт событий без идентификатора
+const announcementBaselineMarker = ""
+
+// QueueEventAnnouncements метод для отметки событий анонсированными и постановки анонсов в очередь рассылок.
+// Анонсы ставятся в очередь в той же транзакции только для событий, которые ещё не анонсировались, поэтому каждое событие
+// анонсирует одна реплика бота. При первом вызове события только запоминаются, иначе при запуске подписчики
+// получили бы анонсы всего каталога; запомненным считается и пустой каталог. Возвращает количество новых событий
+func (s *Storage) QueueEventAnnouncements(ctx context.Context, announcements []models.EventAnnouncement) (int, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opQueueEventAnnouncements, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Реплика, которая вставляет ту же строку параллельно, ждёт фиксации этой транзакции
+	now := time.Now()
+	res, err := tx.ExecContext(ctx,
+		"insert into announced_events (event_id, announced_at) values ($1, $2) on conflict (event_id) do nothing",
+		announcementBaselineMarker, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opQueueEventAnnouncements, err)
+	}
+	baseline, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", opQueueEventAnnouncements, err)
+	}
+
+	claimed := 0
+	for _, a := range announcements {
+		res, err = tx.ExecContext(ctx,
+			"insert into announced_events (event_id, announced_at) values ($1, $2) on conflict (event_id) do nothing",
+			a.EventID, now,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", opQueueEventAnnouncements, err)
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", opQueueEventAnnouncements, err)
+		}
+		if inserted == 0 || baseline > 0 {
+			continue
+		}
+
+		if err = enqueueNotifications(ctx, tx, models.NotificationEventAnnouncement, a, a.ChatIDs, now); err != nil {
+			return 0, fmt.Errorf("%s: %w", opQueueEventAnnouncements, err)
+		}
+		claimed++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", opQueueEventAnnouncements, err)
+	}
+	return claimed, nil
+}
@@ -0,0 +1,122 @@
+package ticket
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Константы для описания операций
+const (
+	opIssue  = "ticket.Issue"
+	opVerify = "ticket.Verify"
+)
+
+// version версия формата полезной нагрузки билета. Билеты первой версии не содержат номера версии
+// и хранят поля без кодирования, поэтому идентификатор события в них не может содержать "|"
+const version = "2"
+
+// fieldSeparator разделитель полей полезной нагрузки
+const fieldSeparator = "|"
+
+// Кастомные ошибки
+var (
+	ErrMalformed        = errors.New("malformed ticket")
+	ErrInvalidSignature = errors.New("invalid ticket signature")
+)
+
+// Ticket описывает билет участника события
+type Ticket struct {
+	EventID string
+	ChatID  int64
+	Nonce   string
+}
+
+// Signer подписывает и проверяет билеты с помощью HMAC-SHA256
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner конструктор для Signer
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Issue создаёт билет со случайным nonce и возвращает его подписанный код вида <payload>.<signature>
+func (s *Signer) Issue(eventID string, chatID int64) (Ticket, string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return Ticket{}, "", fmt.Errorf("%s: %w", opIssue, err)
+	}
+
+	t := Ticket{EventID: eventID, ChatID: chatID, Nonce: hex.EncodeToString(nonce)}
+	// Каждое поле кодируется отдельно: в алфавите base64url нет разделителя, поэтому поля разбираются однозначно
+	fields := []string{
+		version,
+		base64.RawURLEncoding.EncodeToString([]byte(t.EventID)),
+		strconv.FormatInt(t.ChatID, 10),
+		t.Nonce,
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strings.Join(fields, fieldSeparator)))
+
+	return t, payload + "." + s.sign(payload), nil
+}
+
+// Verify проверяет подпись кода и возвращает содержащийся в нём билет
+func (s *Signer) Verify(code string) (Ticket, error) {
+	payload, signature, ok := strings.Cut(strings.TrimSpace(code), ".")
+	if !ok {
+		return Ticket{}, fmt.Errorf("%s: %w", opVerify, ErrMalformed)
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return Ticket{}, fmt.Errorf("%s: %w", opVerify, ErrInvalidSignature)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Ticket{}, fmt.Errorf("%s: %w", opVerify, ErrMalformed)
+	}
+
+	t, err := parseFields(strings.Split(string(raw), fieldSeparator))
+	if err != nil {
+		return Ticket{}, fmt.Errorf("%s: %w", opVerify, err)
+	}
+	return t, nil
+}
+
+// parseFields разбирает поля полезной нагрузки билета текущей или первой версии
+func parseFields(parts []string) (Ticket, error) {
+	var eventID, chatID, nonce string
+	switch {
+	case len(parts) == 4 && parts[0] == version:
+		raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return Ticket{}, ErrMalformed
+		}
+		eventID, chatID, nonce = string(raw), parts[2], parts[3]
+	case len(parts) == 3:
+		eventID, chatID, nonce = parts[0], parts[1], parts[2]
+	default:
+		return Ticket{}, ErrMalformed
+	}
+
+	id, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil || eventID == "" {
+		return Ticket{}, ErrMalformed
+	}
+	return Ticket{EventID: eventID, ChatID: id, Nonce: nonce}, nil
+}
+
+// sign вычисляет подпись полезной нагрузки билета
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
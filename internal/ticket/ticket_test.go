@@ -0,0 +1,88 @@
+package ticket
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		eventID string
+		chatID  int64
+	}{
+		{name: "plain id", eventID: "event-1", chatID: 42},
+		{name: "separator in id", eventID: "a|123|b", chatID: 42},
+		{name: "group chat", eventID: "event-1", chatID: -1001234567890},
+		{name: "unicode id", eventID: "концерт", chatID: 1},
+	}
+
+	s := NewSigner("secret")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issued, code, err := s.Issue(tt.eventID, tt.chatID)
+			if err != nil {
+				t.Fatalf("issue: %v", err)
+			}
+			got, err := s.Verify(code)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if got != issued || got.EventID != tt.eventID || got.ChatID != tt.chatID {
+				t.Errorf("got %+v, want %+v", got, issued)
+			}
+		})
+	}
+}
+
+func TestSignerVerifyRejects(t *testing.T) {
+	s := NewSigner("secret")
+	_, code, err := s.Issue("event-1", 42)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	payload, _, _ := strings.Cut(code, ".")
+
+	// signed подписывает произвольную полезную нагрузку, как это сделал бы владелец секрета
+	signed := func(raw string) string {
+		p := base64.RawURLEncoding.EncodeToString([]byte(raw))
+		return p + "." + s.sign(p)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{name: "other secret", code: func() string { _, c, _ := NewSigner("other").Issue("event-1", 42); return c }(), wantErr: ErrInvalidSignature},
+		{name: "tampered payload", code: payload + "x." + s.sign(payload), wantErr: ErrInvalidSignature},
+		{name: "no signature", code: payload, wantErr: ErrMalformed},
+		{name: "unknown version", code: signed("3|ZXZlbnQ|42|nonce"), wantErr: ErrMalformed},
+		{name: "bad chat id", code: signed("2|ZXZlbnQ|abc|nonce"), wantErr: ErrMalformed},
+		{name: "empty event id", code: signed("2||42|nonce"), wantErr: ErrMalformed},
+		{name: "unencoded separator in legacy ticket", code: signed("a|b|42|nonce"), wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.code); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignerVerifiesLegacyTicket(t *testing.T) {
+	s := NewSigner("secret")
+	p := base64.RawURLEncoding.EncodeToString([]byte("event-1|42|abcd"))
+
+	got, err := s.Verify(p + "." + s.sign(p))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if want := (Ticket{EventID: "event-1", ChatID: 42, Nonce: "abcd"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}